@@ -5,146 +5,971 @@
 package astfrom
 
 import (
+	"bytes"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
+	"go/scanner"
 	"go/token"
+	"io"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Source will return a valid ast.Node from all well formed Go source code. The
 // returned node will never be nil, instead returning a simple *ast.Ident
-// containing the error string if a failure occurs.
+// containing the error string if a failure occurs. Empty source, or source of
+// only whitespace and comments, returns the blank identifier `_`.
+//
+// Source beginning with a brace is ambiguous between a block and a composite
+// literal with an elided type, such as `{1, 2}`. It's parsed as a block like
+// the Go statement grammar unless it's also a valid composite literal and every
+// statement of the block would be meaningless: an expression which is neither
+// a call nor a receive, or any expression given a label. So `{ f() }` and `{}`
+// are blocks while `{1, 2}`, `{x}` and `{x: 1}` are composite literals.
+//
+// A type assertion such as `x.(int)` and a conversion such as `int(x)` are
+// expressions, while `x.(type)` is only valid as the guard of a type switch
+// and fails to parse anywhere else.
+//
+// The returned node is reduced to the smallest meaningful node, removing the
+// scaffolding src was expanded with to be parsed. For example `return x` is
+// parsed within the body of a sentinel func, which is reduced away to leave
+// the *ast.ReturnStmt. Use SourceRaw for the node as it was parsed.
 func Source(src string) ast.Node {
-	node, err := source(src)
+	return SourceWith(src, Options{})
+}
+
+// SourceErr behaves like Source but also returns the error when src could not
+// be parsed. The returned node is never nil, on failure it is the same
+// *ast.Ident containing the error string that Source returns. When no kind
+// could parse src the error is a *ParseError matching ErrUnparseable. The
+// positions within syntax errors are lines and columns of src rather than of
+// the scaffolding it was parsed within.
+func SourceErr(src string) (ast.Node, error) {
+	node, err := Options{}.parse(token.NewFileSet(), src, nil)
+	if err != nil {
+		return errIdent(err), err
+	}
+	return node, nil
+}
+
+// SourceRaw parses src like SourceErr but returns the node exactly as it was
+// produced by the winning parse attempt, before any reduction. Source which
+// parsed as an expression is returned as the expression, while all larger
+// kinds are returned as the *ast.File of the scaffolded source, including the
+// sentinel package clause and func added by the scaffolding. A file with its
+// own package clause is returned as is by both SourceRaw and Source.
+//
+// For example `foo := 42` returns an *ast.File from SourceRaw whose sentinel
+// func body holds the *ast.AssignStmt returned by Source. See Expand for the
+// scaffolding added for each kind.
+func SourceRaw(src string) (ast.Node, error) {
+	var node ast.Node
+	err := recoverFn(func() (err error) {
+		node, err = Options{}.trace(token.NewFileSet(), src, nil)
+		return err
+	})
+	if err != nil {
+		return errIdent(err), err
+	}
+	return node, nil
+}
+
+// Options configures the parsing and reduction performed by SourceWith. The
+// zero value behaves identically to Source.
+type Options struct {
+	// KeepAssign disables unwrapping the scaffolding assignment used to parse
+	// an expression as a statement, such as `_astfrom = f()`, to its right
+	// hand side during reduction, leaving the *ast.AssignStmt.
+	KeepAssign bool
+
+	// Blank is the name assigned to by the scaffolding used to parse an
+	// expression as a statement, defaulting to `_astfrom` when empty. Only an
+	// assignment to this exact name is unwrapped during reduction, so a blank
+	// assignment within src such as `_ = x` is always kept.
+	Blank string
+
+	// Imports are import paths injected into the scaffolded package before
+	// parsing, so the parsed source is consistent with those packages being
+	// in scope. The synthetic import declaration is removed during reduction.
+	Imports []string
+
+	// Placeholder enables template holes, such as `$name := $value` for the
+	// Placeholder '$', when non-zero. Each occurrence of the placeholder
+	// immediately followed by an identifier or keyword, such as `$type`,
+	// outside of literals and comments is replaced by a valid identifier
+	// before parsing, see Holes for locating them afterwards. The placeholder
+	// must be a rune which is illegal within Go source, such as '$' or '@',
+	// so it's never a valid identifier rune, operator or delimiter.
+	Placeholder rune
+
+	// ExprViaFile parses the expression level attempt as the value of the
+	// declaration `var _ = expr` within a scaffolded file, rather than using
+	// parser.ParseExpr. The result is consistent with how all larger kinds are
+	// parsed, which differs from parser.ParseExpr in that a trailing semicolon
+	// such as `f();` is accepted as an expression. The positions of the
+	// returned expression are relative to the scaffolded file.
+	ExprViaFile bool
+
+	// StripShebang allows src to begin with an interpreter line such as
+	// `#!/usr/bin/env gorun`, as used by Go scripts. The leading `#!` is
+	// replaced by `//` before parsing, turning the line into a comment so the
+	// line count and positions of the remaining source are unchanged. Lines
+	// such as `//usr/bin/env go run "$0" "$@"; exit` are already comments and
+	// need no option.
+	StripShebang bool
+
+	// Aggressive extends reduction to complete files written by the user, so
+	// a file declaring exactly one top-level declaration, such as a single
+	// function, reduces to that declaration. The package clause and any doc
+	// comment of the file are discarded. By default only the scaffolded files
+	// created for smaller kinds are reduced.
+	Aggressive bool
+
+	// Mode is passed to go/parser for each parse attempt. With ParseComments
+	// set, comments are attached to the nodes able to hold them, such as the
+	// Comment of an *ast.ValueSpec for `var x = 1 // c` or the Doc of a func.
+	// Reduction keeps these nodes, while comments which go/ast only records
+	// within an *ast.File, such as one trailing a statement, are dropped with
+	// the scaffolded file.
+	//
+	// The PackageClauseOnly and ImportsOnly modes stop parsing early, which
+	// would let every file level attempt succeed, while DeclarationErrors
+	// reports errors the climb would mistake for syntax errors at the wrong
+	// kind. Parsing fails immediately when any of them are set, see Header for
+	// parsing only the package clause and imports. The remaining modes such as
+	// ParseComments, SkipObjectResolution and AllErrors are supported.
+	Mode parser.Mode
+
+	// Logger receives a description of each parse attempt when non-nil, with
+	// the kind, duration and any error of the attempt followed by the indented
+	// source that was parsed. Writes are made as each attempt completes and
+	// errors writing to Logger are ignored.
+	Logger io.Writer
+
+	// OnError selects the node returned in place of one which failed to
+	// parse, an *ast.Ident containing the error by default.
+	OnError ErrorMode
+
+	// Prefer biases the climb toward larger kinds for source which is valid
+	// at more than one, such as `x` which is both an expression and a
+	// statement. The climb begins at Prefer rather than KindExpr, so the
+	// smallest kind at or above Prefer which parses is used. Only when no such
+	// kind parses are the kinds below Prefer attempted, smallest first, so
+	// Prefer never causes valid source to fail. For example `x` is parsed as
+	// an *ast.Ident by default and as an *ast.ExprStmt with a Prefer of
+	// KindDecl, while `package p` is a file for any Prefer. The zero value and
+	// KindExpr climb from the smallest kind.
+	//
+	// Prefer only chooses between the kinds of the climb, none of which place
+	// src in a type position, so it can't make `x` a type. It remains an
+	// expression at every kind, while a lone type is parsed in a type position
+	// by SourceField.
+	Prefer Kind
+}
+
+// ErrorMode is the representation of a source which failed to parse, as
+// returned from SourceWith and CheckWith.
+type ErrorMode int
+
+// The available error modes for Options.OnError.
+const (
+	// ErrorIdent returns an *ast.Ident whose name is the error string.
+	ErrorIdent ErrorMode = iota
+
+	// ErrorBadExpr returns an *ast.BadExpr spanning all of src, with From and
+	// To positions as if src was the only file added to a new token.FileSet.
+	ErrorBadExpr
+
+	// ErrorNil returns a nil node, leaving only the error to describe the
+	// failure where one is returned.
+	ErrorNil
+)
+
+// failed returns the node representing src which failed to parse with err,
+// according to o.OnError.
+func (o Options) failed(src string, err error) ast.Node {
+	switch o.OnError {
+	case ErrorBadExpr:
+		base := token.Pos(1)
+		return &ast.BadExpr{From: base, To: base + token.Pos(len(src))}
+	case ErrorNil:
+		return nil
+	}
+	return errIdent(err)
+}
+
+// unsupportedModes are the parser.Mode bits rejected within Options.Mode.
+const unsupportedModes = parser.PackageClauseOnly | parser.ImportsOnly |
+	parser.DeclarationErrors
+
+// validate returns an error if o can not be used for parsing.
+func (o Options) validate() error {
+	if bits := o.Mode & unsupportedModes; bits != 0 {
+		return fmt.Errorf("unsupported parser mode bits %#x in Options.Mode", uint(bits))
+	}
+	if !o.Prefer.Between(KindNode, KindPkg) {
+		return fmt.Errorf("invalid kind %v in Options.Prefer", int(o.Prefer))
+	}
+	if o.Placeholder != 0 && !holeRune(o.Placeholder) {
+		return fmt.Errorf("invalid rune %q in Options.Placeholder", o.Placeholder)
+	}
+	return nil
+}
+
+// climb returns the kinds attempted in order, beginning at o.Prefer and
+// falling back to the kinds below it.
+func (o Options) climb() []Kind {
+	start := KindExpr
+	if o.Prefer > start {
+		start = o.Prefer
+	}
+	kinds := make([]Kind, 0, KindPkg)
+	for from := start; from <= KindPkg; from++ {
+		kinds = append(kinds, from)
+	}
+	for from := KindExpr; from < start; from++ {
+		kinds = append(kinds, from)
+	}
+	return kinds
+}
+
+// SourceWith behaves like Source using the given Options, returning the node
+// selected by Options.OnError when src fails to parse.
+func SourceWith(src string, opts Options) ast.Node {
+	node, err := opts.parse(token.NewFileSet(), src, nil)
+	if err != nil {
+		return opts.failed(src, err)
+	}
+	return node
+}
+
+// SourceFormatted parses and reduces src like Source, returning the reduced
+// node formatted by go/format. The node is printed using the positions it was
+// parsed with, so the line breaks of src are kept where gofmt allows. Comments
+// are not retained. An error is returned if src could not be parsed or the
+// reduced node could not be formatted.
+func SourceFormatted(src string) (string, error) {
+	fset := token.NewFileSet()
+	node, err := Options{}.parse(fset, src, nil)
+	if err != nil {
+		return ``, err
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		return ``, fmt.Errorf("unable to format %T: %v", node, err)
+	}
+	return buf.String(), nil
+}
+
+// SourceTrace behaves like Source but also returns each parse attempt made
+// while climbing from the expression level towards a full package. The final
+// attempt is the one that produced the returned node, or the last failure.
+func SourceTrace(src string) (ast.Node, []Attempt) {
+	var attempts []Attempt
+	node, err := Options{}.parse(token.NewFileSet(), src, func(a Attempt) {
+		attempts = append(attempts, a)
+	})
 	if err != nil {
-		return errIdent(err)
+		return errIdent(err), attempts
 	}
-	return reduce(node)
+	return node, attempts
+}
+
+// SourceStmt parses src as a single statement. Expressions are grown into the
+// statement form, an *ast.ExprStmt for calls and receives and a blank
+// assignment `_ = expr` otherwise, while local declarations are wrapped in an
+// *ast.DeclStmt. An error is returned if src only parses as a top-level
+// declaration, file or package.
+func SourceStmt(src string) (ast.Stmt, error) {
+	var last Attempt
+	node, err := Options{}.parse(token.NewFileSet(), src, func(a Attempt) {
+		last = a
+	})
+	if err != nil {
+		return nil, err
+	}
+	if last.Kind >= KindFile {
+		return nil, fmt.Errorf("expected statement, found %v", last.Kind)
+	}
+
+	switch T := node.(type) {
+	case ast.Stmt:
+		return T, nil
+	case *ast.GenDecl:
+		return &ast.DeclStmt{Decl: T}, nil
+	case *ast.CallExpr:
+		return &ast.ExprStmt{X: T}, nil
+	case *ast.UnaryExpr:
+		if T.Op == token.ARROW {
+			return &ast.ExprStmt{X: T}, nil
+		}
+		return blankAssign(T), nil
+	case ast.Expr:
+		return blankAssign(T), nil
+	default:
+		return nil, fmt.Errorf("expected statement, found %T", T)
+	}
+}
+
+// blankAssign returns the assignment `_ = x`.
+func blankAssign(x ast.Expr) *ast.AssignStmt {
+	return &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(`_`)},
+		Tok: token.ASSIGN,
+		Rhs: []ast.Expr{x},
+	}
+}
+
+// Attempt describes a single parse of src expanded to Kind.
+type Attempt struct {
+	Kind     Kind
+	Src      string
+	Err      error
+	Duration time.Duration
+
+	// offset is the byte offset of the source being parsed within Src.
+	offset int
+}
+
+// log writes a description of a to w for Options.Logger.
+func (a Attempt) log(w io.Writer) {
+	var b strings.Builder
+	if a.Err != nil {
+		fmt.Fprintf(&b, "astfrom: %v attempt failed in %v: %v\n", a.Kind, a.Duration, a.Err)
+	} else {
+		fmt.Fprintf(&b, "astfrom: %v attempt succeeded in %v\n", a.Kind, a.Duration)
+	}
+	for _, line := range strings.Split(strings.TrimRight(a.Src, "\n"), "\n") {
+		b.WriteString("\t" + line + "\n")
+	}
+	io.WriteString(w, b.String())
+}
+
+// parse will climb the targets for src and reduce the result within recoverFn,
+// so a panic during either step is returned as an error.
+func (o Options) parse(fset *token.FileSet, src string, fn func(Attempt)) (ast.Node, error) {
+	_, node, err := o.parseRaw(fset, src, fn)
+	return node, err
+}
+
+// parseRaw behaves like parse but also returns the node before reduction.
+// Errors verifying the reduced node are mapped to positions within src.
+func (o Options) parseRaw(fset *token.FileSet, src string, fn func(Attempt)) (raw, node ast.Node, err error) {
+	var last Attempt
+	err = recoverFn(func() (err error) {
+		raw, err = o.trace(fset, src, func(a Attempt) {
+			if last = a; fn != nil {
+				fn(a)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if err = o.verifyReduce(raw); err != nil {
+			return err
+		}
+		node = o.reduce(raw)
+		if err = verifyNode(fset, node); err != nil {
+			m, _ := o.sourceMap(src)
+			return m.mapErr(err, last)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, node, nil
 }
 
 func source(src string) (ast.Node, error) {
+	return trace(token.NewFileSet(), src, nil)
+}
+
+func trace(fset *token.FileSet, src string, fn func(Attempt)) (ast.Node, error) {
+	return Options{}.trace(fset, src, fn)
+}
+
+// trace will call fn, when non-nil, after each parse attempt. Each attempt is
+// added to fset.
+func (o Options) trace(fset *token.FileSet, src string, fn func(Attempt)) (ast.Node, error) {
 	var (
-		err  error
-		node ast.Node
+		err      error
+		node     ast.Node
+		attempts []Attempt
 	)
-	for cur, from := src, targetExpr; from <= targetPkg; from++ {
+	if err = o.validate(); err != nil {
+		return nil, err
+	}
+	m, src := o.sourceMap(src)
+	if len(src) == 0 || commentOnly(src) {
+		src = `_`
+	}
+	report := func(a Attempt) {
+		if fn != nil {
+			fn(a)
+		}
+		if o.Logger != nil {
+			a.log(o.Logger)
+		}
+	}
+	if err = checkNesting(src); err != nil {
+		// Record the rejection as a failed attempt at the first kind of the
+		// climb, so callers inspecting the attempts always have one.
+		a := Attempt{Kind: o.climb()[0], Src: src, Err: err}
+		report(a)
+		return nil, &ParseError{Attempts: []Attempt{a}, m: m}
+	}
+	for _, from := range o.climb() {
+		cur, offset := src, 0
+		if from > KindExpr {
+			cur = o.expand(src, from, KindPkg)
+		}
+		start := time.Now()
 		switch from {
-		case targetExpr:
+		case KindExpr:
+			if o.ExprViaFile {
+				cur = o.exprFile(src)
+				offset = strings.Index(o.exprFile(scaffoldMark), scaffoldMark)
+			}
 			err = recoverFn(func() (err error) {
-				node, err = parser.ParseExpr(cur)
+				if o.ExprViaFile {
+					node, err = o.parseExprFile(fset, cur)
+					return err
+				}
+				node, err = parser.ParseExprFrom(fset, `string.go`, cur, o.Mode)
 				return err
 			})
+			if err != nil && strings.HasPrefix(src, `{`) {
+				if lit, lerr := elidedLit(fset, src); lerr == nil {
+					node, err = lit, nil
+					cur, offset = litFile(src), len(litSentinel)+1
+				}
+			}
 		default:
+			offset = strings.Index(o.expand(scaffoldMark, from, KindPkg), scaffoldMark)
 			err = recoverFn(func() (err error) {
-				node, err = parser.ParseFile(token.NewFileSet(), `string.go`, cur, 0)
-				return err
+				if node, err = parser.ParseFile(fset, `string.go`, cur, o.Mode); err != nil {
+					return err
+				}
+				return verifyScaffold(node)
 			})
 		}
+		a := Attempt{
+			Kind: from, Src: cur, Err: err, Duration: time.Since(start), offset: offset}
+		report(a)
 		if err == nil {
 			break
 		}
-		cur = expand(src, from+1, targetPkg)
+		attempts = append(attempts, a)
 	}
 	if err != nil {
-		return nil, err
+		return nil, &ParseError{Attempts: attempts, m: m}
 	}
 	return node, nil
 }
 
+// exprFile returns the scaffolded file used to parse src as an expression when
+// o.ExprViaFile is set.
+func (o Options) exprFile(src string) string {
+	return o.expand("var _ = "+src+"\n", KindFile, KindPkg)
+}
+
+// parseExprFile parses the file returned from exprFile, returning the value of
+// its single declaration.
+func (o Options) parseExprFile(fset *token.FileSet, src string) (ast.Expr, error) {
+	file, err := parser.ParseFile(fset, `string.go`, src, o.Mode)
+	if err != nil {
+		return nil, err
+	}
+	if decls := o.decls(file); len(decls) == 1 {
+		if gd, ok := decls[0].(*ast.GenDecl); ok && len(gd.Specs) == 1 {
+			if vs := gd.Specs[0].(*ast.ValueSpec); len(vs.Values) == 1 {
+				return vs.Values[0], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%v: expected a single expression", fset.Position(file.Pos()))
+}
+
+// elidedLit parses src, which begins with a brace, as a composite literal with
+// an elided type when it's more likely to be one than a block as described by
+// Source.
+func elidedLit(fset *token.FileSet, src string) (ast.Expr, error) {
+	var lit *ast.CompositeLit
+	err := recoverFn(func() error {
+		expr, err := parser.ParseExprFrom(fset, `string.go`, litFile(src), 0)
+		if err != nil {
+			return err
+		}
+		if outer := expr.(*ast.CompositeLit); len(outer.Elts) == 1 {
+			lit, _ = outer.Elts[0].(*ast.CompositeLit)
+		}
+		if lit == nil || lit.Type != nil {
+			return fmt.Errorf("expected composite literal, found %v", src)
+		}
+
+		file, err := parser.ParseFile(
+			fset, `string.go`, expand(src, KindBlock, KindPkg), 0)
+		if err != nil {
+			return nil
+		}
+		body := file.Decls[0].(*ast.FuncDecl).Body
+		if len(body.List) == 0 {
+			return fmt.Errorf("expected block, found %v", src)
+		}
+		for _, stmt := range body.List {
+			if !litStmt(stmt) {
+				return fmt.Errorf("expected block, found %v", src)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lit, nil
+}
+
+// litFile returns the source used to parse src as an element of a composite
+// literal.
+func litFile(src string) string {
+	return litSentinel + "{" + trimComment(src) + ",\n}"
+}
+
+// trimComment returns src without any comments following its last token.
+func trimComment(src string) string {
+	var (
+		s   scanner.Scanner
+		end int
+	)
+	fset := token.NewFileSet()
+	file := fset.AddFile(``, fset.Base(), len(src))
+	s.Init(file, []byte(src), nil, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return src[:end]
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		end = file.Offset(pos) + len(lit)
+		if lit == `` {
+			end = file.Offset(pos) + len(tok.String())
+		}
+	}
+}
+
+// maxNesting is the deepest nesting of parens, brackets and braces accepted
+// by checkNesting, far beyond any practical source.
+const maxNesting = 5000
+
+// checkNesting returns an error when src nests deeper than maxNesting, which
+// rejects pathological source before it reaches the recursive descent of
+// go/parser. Brackets within literals and comments are ignored.
+func checkNesting(src string) error {
+	if strings.Count(src, "(")+strings.Count(src, "[")+strings.Count(src, "{") <= maxNesting {
+		return nil
+	}
+
+	var (
+		s     scanner.Scanner
+		depth int
+	)
+	fset := token.NewFileSet()
+	file := fset.AddFile(``, fset.Base(), len(src))
+	s.Init(file, []byte(src), nil, 0)
+	for {
+		pos, tok, _ := s.Scan()
+		switch tok {
+		case token.EOF:
+			return nil
+		case token.LPAREN, token.LBRACK, token.LBRACE:
+			if depth++; depth > maxNesting {
+				return fmt.Errorf("%v: source exceeds the maximum nesting depth of %v",
+					fset.Position(pos), maxNesting)
+			}
+		case token.RPAREN, token.RBRACK, token.RBRACE:
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+}
+
+// litStmt reports whether stmt within a block is more likely an element of a
+// composite literal.
+func litStmt(stmt ast.Stmt) bool {
+	switch T := stmt.(type) {
+	case *ast.LabeledStmt:
+		_, ok := T.Stmt.(*ast.ExprStmt)
+		return ok
+	case *ast.ExprStmt:
+		switch X := T.X.(type) {
+		case *ast.CallExpr:
+			return false
+		case *ast.UnaryExpr:
+			return X.Op != token.ARROW
+		}
+		return true
+	}
+	return false
+}
+
+// stripShebang comments out the interpreter line of src when o.StripShebang is
+// set.
+func (o Options) stripShebang(src string) string {
+	if !o.StripShebang || !strings.HasPrefix(src, `#!`) {
+		return src
+	}
+	return `//` + src[2:]
+}
+
+// verifyScaffold returns an error if the scaffolding of node was consumed by the
+// source placed within it. For example a leading line comment would hide the
+// body of the sentinel func, declaring it without one.
+func verifyScaffold(node ast.Node) error {
+	file, ok := node.(*ast.File)
+	if !ok || file.Name.Name != pkgSentinel {
+		return nil
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if ok && fn.Name.Name == fnSentinelName && fn.Body == nil {
+			return fmt.Errorf("%v: expected '{', found newline", fnSentinelName)
+		}
+	}
+	return nil
+}
+
+// verifyReduce returns errPkgSentinel if node is a file in the sentinel package
+// without the declarations its scaffolding would have, which happens when src
+// is itself a file of that package rather than being expanded into one.
+func (o Options) verifyReduce(node ast.Node) error {
+	file, ok := node.(*ast.File)
+	if ok && file.Name.Name == pkgSentinel && len(o.decls(file)) == 0 {
+		return errPkgSentinel
+	}
+	return nil
+}
+
+// commentOnly reports whether src holds nothing but comments, which would
+// otherwise parse as an empty block.
+func commentOnly(src string) bool {
+	var s scanner.Scanner
+	file := token.NewFileSet().AddFile(``, -1, len(src))
+	s.Init(file, []byte(src), nil, 0)
+	_, tok, _ := s.Scan()
+	return tok == token.EOF && s.ErrorCount == 0
+}
+
+// trimLines removes the blank lines surrounding src, leaving the interior lines
+// and the indentation of the first non-blank line untouched.
+func trimLines(src string) string {
+	lead := src[:len(src)-len(strings.TrimLeft(src, " \t\r\n"))]
+	if idx := strings.LastIndexByte(lead, '\n'); idx >= 0 {
+		src = src[idx+1:]
+	}
+	return strings.TrimRight(src, " \t\r\n")
+}
+
 const (
 	pkgSentinel    = `astfrom`
 	fnSentinelName = `astfromFunc`
 	fnSentinel     = fnSentinelName + `()`
+	litSentinel    = `astfromLit`
+	blankSentinel  = `_astfrom`
+
+	// scaffoldMark is expanded in place of source to locate it within the
+	// scaffolding.
+	scaffoldMark = "\x00"
 )
 
-func expand(src string, from, to target) string {
-	src = expandExpr(src, from, to)
+// Expand returns src, source of the from kind, wrapped in the scaffolding used
+// by Source to parse it as the larger to kind. For example expanding `x` from
+// KindExpr to KindFile returns a sentinel func whose body assigns x to the
+// sentinel name `_astfrom`. Expanding to KindPkg adds a sentinel package
+// clause, while kinds beyond KindPkg add nothing further. The src is returned
+// unchanged when from is not smaller than to.
+func Expand(src string, from, to Kind) string {
+	if from >= to {
+		return src
+	}
+	return expand(src, from, to)
+}
+
+func expand(src string, from, to Kind) string {
+	return Options{}.expand(src, from, to)
+}
+
+// expand will expand src like expand, assigning expressions to o.Blank and
+// adding the import declaration for o.Imports when the package clause is
+// added.
+func (o Options) expand(src string, from, to Kind) string {
+	src = expandExpr(src, o.blank(), from, to)
 	src = expandFile(src, from, to)
+	if len(o.Imports) > 0 && to >= KindPkg && KindPkg > from {
+		clause := "package " + pkgSentinel + "\n\n"
+		src = clause + o.importDecl() + src[len(clause):]
+	}
 	return src
 }
 
-func expandExpr(src string, from, to target) string {
+func (o Options) importDecl() string {
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, path := range o.Imports {
+		b.WriteString("\t" + strconv.Quote(path) + "\n")
+	}
+	b.WriteString(")\n\n")
+	return b.String()
+}
+
+// blank returns the name assigned to by the expression scaffolding.
+func (o Options) blank() string {
+	if len(o.Blank) > 0 {
+		return o.Blank
+	}
+	return blankSentinel
+}
+
+func expandExpr(src, blank string, from, to Kind) string {
 	if len(src) == 0 {
 		src = `_`
 	}
-	if to >= targetDecl && targetDecl > from {
-		src = "_ = " + src
+	if to >= KindDecl && KindDecl > from {
+		src = blank + " = " + src
 	}
-	if to >= targetStmt && targetStmt > from {
+	if to >= KindStmt && KindStmt > from {
 		src = "\t" + src + "\n"
 	}
 	return src
 }
 
-func expandFile(src string, from, to target) string {
-	if to >= targetBlock && targetBlock > from {
+func expandFile(src string, from, to Kind) string {
+	if to >= KindBlock && KindBlock > from {
 		src = "{\n" + strings.TrimRight(src, "\n\t") + "\n}\n"
 	}
-	if to >= targetFile && targetFile > from && from <= targetBlock {
+	if to >= KindFile && KindFile > from && from <= KindBlock {
 		src = "func " + fnSentinel + " " + src
 	}
-	if to >= targetPkg && targetPkg > from {
+	if to >= KindPkg && KindPkg > from {
 		src = "package " + pkgSentinel + "\n\n" + src
 	}
 	return src
 }
 
 func reduce(node ast.Node) ast.Node {
+	return Options{}.reduce(node)
+}
+
+func (o Options) reduce(node ast.Node) ast.Node {
 	switch T := node.(type) {
 	case *ast.File:
 		if T.Name.Name == pkgSentinel {
-			return reduce(T.Decls[0])
+			return o.reduce(o.decls(T)[0])
+		}
+		if o.Aggressive && len(T.Decls) == 1 {
+			return o.reduce(T.Decls[0])
 		}
 	case *ast.FuncDecl:
 		if T.Name.Name == fnSentinelName {
-			return reduce(T.Body)
+			return o.reduce(T.Body)
 		}
 	case *ast.BlockStmt:
 		if len(T.List) == 1 {
-			return reduce(T.List[0])
+			return o.reduce(T.List[0])
 		}
 	case *ast.DeclStmt:
 		return T.Decl
 	case *ast.AssignStmt:
+		if o.KeepAssign {
+			break
+		}
 		id, ok := T.Lhs[0].(*ast.Ident)
-		if ok && len(T.Lhs) == 1 && id.Name == "_" {
+		if ok && len(T.Lhs) == 1 && len(T.Rhs) == 1 && id.Name == o.blank() {
 			return T.Rhs[0]
 		}
 	}
 	return node
 }
 
-// target specifies the target node type.
-type target int
+// decls returns the declarations of the sentinel file f, excluding the import
+// declaration injected for o.Imports.
+func (o Options) decls(f *ast.File) []ast.Decl {
+	if len(f.Decls) > 0 && o.injected(f, f.Decls[0]) {
+		return f.Decls[1:]
+	}
+	return f.Decls
+}
+
+// injected reports whether decl is the import declaration injected for
+// o.Imports, which imports each path in order directly after the package
+// clause of f.
+func (o Options) injected(f *ast.File, decl ast.Decl) bool {
+	gen, ok := decl.(*ast.GenDecl)
+	if !ok || gen.Tok != token.IMPORT || len(gen.Specs) != len(o.Imports) || len(o.Imports) == 0 {
+		return false
+	}
+	if gen.TokPos != f.Package+token.Pos(len("package "+pkgSentinel+"\n\n")) {
+		return false
+	}
+	for idx, spec := range gen.Specs {
+		if spec.(*ast.ImportSpec).Path.Value != strconv.Quote(o.Imports[idx]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Kind specifies the target node type.
+type Kind int
 
-// The available target modes, ordered in smallest to largest.
+// The available target kinds, ordered in smallest to largest. Each names a
+// level of the climb, which is the scaffolding src is placed in rather than
+// the node it holds: KindExpr parses an expression, KindDecl, KindStmt and
+// KindBlock parse the statements of a function body, KindFile parses the
+// declarations of a file and KindPkg parses a complete file. So `x := 1` and
+// `var x = 1` are both KindDecl, while `func f() {}` is KindFile, see KindOf.
 const (
-	targetNode target = iota
-	targetExpr
-	targetDecl
-	targetStmt
-	targetBlock
-	targetFile
-	targetPkg
+	KindNode Kind = iota
+	KindExpr
+	KindDecl
+	KindStmt
+	KindBlock
+	KindFile
+	KindPkg
 )
 
-var targetStrings = [...]string{
-	targetNode:  "Node",
-	targetExpr:  "Expr",
-	targetDecl:  "Decl",
-	targetStmt:  "Stmt",
-	targetBlock: "Block",
-	targetFile:  "File",
-	targetPkg:   "Pkg",
+var kindStrings = [...]string{
+	KindNode:  "Node",
+	KindExpr:  "Expr",
+	KindDecl:  "Decl",
+	KindStmt:  "Stmt",
+	KindBlock: "Block",
+	KindFile:  "File",
+	KindPkg:   "Pkg",
+}
+
+// String implements fmt.Stringer by returning the name of the kind.
+func (s Kind) String() string {
+	if KindNode > s || s > KindPkg {
+		s = KindNode
+	}
+	return kindStrings[s]
+}
+
+// Next returns the next larger kind, clamped at KindPkg. Kinds smaller than
+// KindNode return KindNode.
+func (s Kind) Next() Kind {
+	switch {
+	case s < KindNode:
+		return KindNode
+	case s >= KindPkg:
+		return KindPkg
+	}
+	return s + 1
+}
+
+// Prev returns the next smaller kind, clamped at KindNode. Kinds larger than
+// KindPkg return KindPkg.
+func (s Kind) Prev() Kind {
+	switch {
+	case s <= KindNode:
+		return KindNode
+	case s > KindPkg:
+		return KindPkg
+	}
+	return s - 1
+}
+
+// Between reports whether s is within the inclusive range of kinds from a to
+// b, which may be given in either order.
+func (s Kind) Between(a, b Kind) bool {
+	if a > b {
+		a, b = b, a
+	}
+	return a <= s && s <= b
+}
+
+// KindOf returns the Kind of the climb at which node is parsed, matching the
+// Kind of a Result for src which reduces to node: KindExpr for any ast.Expr
+// including composite and function literals, KindDecl for any ast.Stmt and
+// for a const, type or var declaration which parse as statements, KindFile for
+// an import or function declaration and KindPkg for a *ast.File or
+// *ast.Package. Any other node, such as a field, spec or comment, is KindNode.
+func KindOf(node ast.Node) Kind {
+	switch T := node.(type) {
+	case ast.Expr:
+		return KindExpr
+	case ast.Stmt:
+		return KindDecl
+	case *ast.GenDecl:
+		if T.Tok != token.IMPORT {
+			return KindDecl
+		}
+		return KindFile
+	case ast.Decl:
+		return KindFile
+	case *ast.File, *ast.Package:
+		return KindPkg
+	}
+	return KindNode
+}
+
+// PlacementKinds returns the kinds of the climb, smallest first, at which node
+// may be placed, directly or wrapped in the node Go requires there, beginning
+// with KindOf(node). Any ast.Expr is placed as KindExpr, and among statements
+// at KindDecl and KindStmt within an *ast.ExprStmt when it's a call or receive
+// which may appear in statement context. Any ast.Stmt is placed at KindDecl
+// and KindStmt, with a *ast.BlockStmt also placed as the body of KindBlock. A
+// const, type or var declaration is placed among statements within an
+// *ast.DeclStmt and among the declarations of KindFile and KindPkg, while any
+// other ast.Decl is only placed at KindFile and KindPkg. A *ast.File or
+// *ast.Package is only KindPkg, while any other node such as a field or spec
+// has no placement and returns nil.
+//
+// Placement is syntactic, so a conversion such as `int(x)` is placed as a
+// statement like any other call, even though it would fail to type check.
+func PlacementKinds(node ast.Node) []Kind {
+	switch T := node.(type) {
+	case ast.Expr:
+		if stmtExpr(T) {
+			return []Kind{KindExpr, KindDecl, KindStmt}
+		}
+		return []Kind{KindExpr}
+	case *ast.BlockStmt:
+		return []Kind{KindDecl, KindStmt, KindBlock}
+	case ast.Stmt:
+		return []Kind{KindDecl, KindStmt}
+	case *ast.GenDecl:
+		if T.Tok != token.IMPORT {
+			return []Kind{KindDecl, KindStmt, KindFile, KindPkg}
+		}
+		return []Kind{KindFile, KindPkg}
+	case ast.Decl:
+		return []Kind{KindFile, KindPkg}
+	case *ast.File, *ast.Package:
+		return []Kind{KindPkg}
+	}
+	return nil
 }
 
-// String implements fmt.Stringer by returning the name of the target.
-func (s target) String() string {
-	if targetNode > s || s > targetPkg {
-		s = targetNode
+// stmtExpr reports whether x may be used as an expression statement, which
+// is a call or receive operation optionally within parentheses.
+func stmtExpr(x ast.Expr) bool {
+	switch T := ast.Unparen(x).(type) {
+	case *ast.CallExpr:
+		return true
+	case *ast.UnaryExpr:
+		return T.Op == token.ARROW
 	}
-	return targetStrings[s]
+	return false
 }
 
 // errIdent will return an *ast.Ident to represent the given error in place of
@@ -1,17 +1,24 @@
 package astfrom
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
 	"reflect"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 )
 
 const (
 	trgExpr  = "_"
-	trgDecl  = "_ = " + trgExpr
+	trgDecl  = blankSentinel + " = " + trgExpr
 	trgStmt  = "\t" + trgDecl + "\n"
 	trgBlock = "{\n" + trgStmt + "}\n"
 	trgFile  = "func " + fnSentinel + " " + trgBlock
@@ -29,88 +36,1392 @@ var (
 	astFile            = &ast.File{}
 )
 
+// sprint formats node, returning an empty string for a nil node.
+func sprint(t testing.TB, node ast.Node) string {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return ``
+	}
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), node); err != nil {
+		t.Fatalf(`exp nil err from format.Node; got %v`, err)
+	}
+	return buf.String()
+}
+
 func TestSource(t *testing.T) {
 	type test struct {
-		exp       ast.Node
-		expReduce ast.Node
-		src       string
+		exp       ast.Node
+		expReduce ast.Node
+		src       string
+	}
+	tests := []test{
+		{astExpr, astExpr, `foo`},
+		{astExpr, astExpr, "myIdent"},
+		{astExpr, astExpr, `int64`},
+		{astLit, astLit, "42"},
+		{astCall, astCall, "myIdent()"},
+		{astFile, astAssign, "foo := 42"},
+		{astFile, astAssign, "{ foo := 42 }"},
+		{astFile, astDecl, "type foo string"},
+		{astFile, astDecl, `const int = 5`},
+		{astFile, astStmt, `if true {};`},
+		{astFile, &ast.DeferStmt{}, `defer f()`},
+		{astFile, &ast.GoStmt{}, `go f(x, y)`},
+		{astFile, &ast.ReturnStmt{}, `return`},
+		{astFile, &ast.ReturnStmt{}, `return x`},
+		{astFile, &ast.ReturnStmt{}, `return x, f()`},
+		{astFile, astBlock, `{ var i int64 = 10; s := i+1 };`},
+		{astFile, astFile, `package main;`},
+		{astFile, astFile,
+			`// Package p
+			package p
+			import "fmt"; func f() { fmt.Println("Hello, World!") };`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%va - from src %q exp %[3]T`, idx, test.src, test.exp)
+
+		got, err := source(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from source; got %v`, err)
+		}
+
+		expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
+		if expTyp != gotTyp {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+		}
+
+		t.Logf(`test #%vb - from src %q exp %[3]T`, idx, test.src, test.expReduce)
+		got = reduce(got)
+		expTyp, gotTyp = reflect.TypeOf(test.expReduce), reflect.TypeOf(got)
+		if expTyp != gotTyp {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+		}
+
+	}
+}
+
+func TestSourceErr(t *testing.T) {
+	node, err := SourceErr(`foo := 42`)
+	if err != nil {
+		t.Fatalf(`exp nil err from SourceErr; got %v`, err)
+	}
+	if _, ok := node.(*ast.AssignStmt); !ok {
+		t.Fatalf(`exp *ast.AssignStmt from SourceErr; got %T`, node)
+	}
+
+	node, err = SourceErr(`{`)
+	if err == nil {
+		t.Fatal(`exp non-nil err from SourceErr`)
+	}
+	id, ok := node.(*ast.Ident)
+	if !ok {
+		t.Fatalf(`exp *ast.Ident from SourceErr; got %T`, node)
+	}
+	if exp, got := err.Error(), id.Name; exp != got {
+		t.Fatalf(`exp ident named %q; got %q`, exp, got)
+	}
+}
+
+func TestSourceRaw(t *testing.T) {
+	type test struct {
+		src     string
+		raw     ast.Node
+		reduced ast.Node
+	}
+	tests := []test{
+		{`foo`, astExpr, astExpr},
+		{`a + b`, &ast.BinaryExpr{}, &ast.BinaryExpr{}},
+		{`{1, 2}`, &ast.CompositeLit{}, &ast.CompositeLit{}},
+		{`foo := 42`, astFile, astAssign},
+		{`_ = foo`, astFile, astAssign},
+		{`_astfrom = foo`, astFile, astExpr},
+		{`return x`, astFile, &ast.ReturnStmt{}},
+		{`a := 1; b := 2`, astFile, astBlock},
+		{`var x int`, astFile, &ast.GenDecl{}},
+		{`func f() {}`, astFile, &ast.FuncDecl{}},
+		{`package main`, astFile, astFile},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T and %T`, idx, test.src, test.raw, test.reduced)
+
+		raw, err := SourceRaw(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceRaw; got %v`, err)
+		}
+		if exp, got := reflect.TypeOf(test.raw), reflect.TypeOf(raw); exp != got {
+			t.Fatalf(`exp %v from SourceRaw; got %v`, exp, got)
+		}
+		if exp, got := reflect.TypeOf(test.reduced), reflect.TypeOf(Source(test.src)); exp != got {
+			t.Fatalf(`exp %v from Source; got %v`, exp, got)
+		}
+		if exp, got := Source(test.src), reduce(raw); !Equal(exp, got) {
+			t.Fatalf("exp reducing the raw node to equal Source:\n%v", Diff(exp, got))
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		node, err := SourceRaw(`{`)
+		if err == nil {
+			t.Fatal(`exp non-nil err from SourceRaw`)
+		}
+		if id, ok := node.(*ast.Ident); !ok || id.Name != err.Error() {
+			t.Fatalf(`exp *ast.Ident containing the error; got %T`, node)
+		}
+	})
+}
+
+func TestSourceReducePanic(t *testing.T) {
+	// The sentinel package name collides with the scaffolding, which must be
+	// rejected rather than reduced as if it were scaffolding.
+	for idx, src := range []string{
+		`package ` + pkgSentinel,
+		"// Package doc.\npackage " + pkgSentinel + "\n",
+	} {
+		t.Logf(`test #%v - from src %q exp error ident`, idx, src)
+
+		node, err := SourceErr(src)
+		if err == nil {
+			t.Fatal(`exp non-nil err from SourceErr`)
+		}
+		if !errors.Is(err, errPkgSentinel) {
+			t.Fatalf(`exp errPkgSentinel from SourceErr; got %v`, err)
+		}
+		if exp, got := errPkgSentinel.Error(), err.Error(); exp != got {
+			t.Fatalf(`exp err %q; got %q`, exp, got)
+		}
+		if _, ok := node.(*ast.Ident); !ok {
+			t.Fatalf(`exp *ast.Ident from SourceErr; got %T`, node)
+		}
+		if _, ok := Source(src).(*ast.Ident); !ok {
+			t.Fatalf(`exp *ast.Ident from Source; got %T`, Source(src))
+		}
+		if _, attempts := SourceTrace(src); len(attempts) == 0 {
+			t.Fatal(`exp attempts from SourceTrace`)
+		}
+		if _, err := SourceStmt(src); err == nil {
+			t.Fatal(`exp non-nil err from SourceStmt`)
+		}
+		if _, _, err := Check(src); err != errPkgSentinel {
+			t.Fatalf(`exp errPkgSentinel from Check; got %v`, err)
+		}
+
+		// The file has no injected import for Options.Imports to remove.
+		opts := Options{Imports: []string{`fmt`}}
+		if _, err := opts.parse(token.NewFileSet(), src, nil); err != errPkgSentinel {
+			t.Fatalf(`exp errPkgSentinel with Options.Imports; got %v`, err)
+		}
+		if _, ok := SourceWith(src, opts).(*ast.Ident); !ok {
+			t.Fatalf(`exp *ast.Ident from SourceWith; got %T`, SourceWith(src, opts))
+		}
+	}
+	t.Run(`Imports`, func(t *testing.T) {
+		// A file of the sentinel package importing the same paths isn't the
+		// scaffolding, as its import doesn't directly follow the clause.
+		src := "package " + pkgSentinel + "\n\n\nimport \"fmt\"\n\nvar x = fmt.Sprint()"
+		node := SourceWith(src, Options{Imports: []string{`fmt`}})
+		if gen, ok := node.(*ast.GenDecl); !ok || gen.Tok != token.IMPORT {
+			t.Fatalf(`exp the import *ast.GenDecl of src; got %T`, node)
+		}
+	})
+}
+
+func TestSourceScaffold(t *testing.T) {
+	// A leading line comment hides the body of the sentinel func at the block
+	// level, which must be rejected rather than reduced.
+	type test struct {
+		src string
+		exp ast.Node
+	}
+	tests := []test{
+		{"//export\nfunc F() {}", &ast.FuncDecl{}},
+		{"// F does things.\nfunc F() {}", &ast.FuncDecl{}},
+		{"// v is a var.\nvar v = 1", astDecl},
+		{"// x\nx := 1", astAssign},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T`, idx, test.src, test.exp)
+
+		got, err := SourceErr(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceErr; got %v`, err)
+		}
+		expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
+		if expTyp != gotTyp {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+		}
+	}
+
+	if _, err := SourceErr(`func ` + fnSentinel); err == nil {
+		t.Fatal(`exp non-nil err from SourceErr`)
+	}
+	if _, err := SourceList(`func ` + fnSentinel); err == nil {
+		t.Fatal(`exp non-nil err from SourceList`)
+	}
+}
+
+func TestSourceWith(t *testing.T) {
+	t.Run(`KeepAssign`, func(t *testing.T) {
+		type test struct {
+			src  string
+			opts Options
+			exp  ast.Node
+		}
+		tests := []test{
+			{`_astfrom = someCall()`, Options{}, astCall},
+			{`_astfrom = someCall()`, Options{KeepAssign: true}, astAssign},
+			{`_astfrom = x`, Options{}, astExpr},
+			{`_astfrom = x`, Options{KeepAssign: true}, astAssign},
+			{`_ = x`, Options{}, astAssign},
+			{`_ = someCall()`, Options{}, astAssign},
+			{`_ = x`, Options{Blank: `_`}, astExpr},
+			{`tmp = x`, Options{Blank: `tmp`}, astExpr},
+			{`_astfrom = x`, Options{Blank: `tmp`}, astAssign},
+			{`_astfrom, y = x, y`, Options{}, astAssign},
+			{`x := 1`, Options{}, astAssign},
+			{`x := 1`, Options{KeepAssign: true}, astAssign},
+			{`someCall()`, Options{KeepAssign: true}, astCall},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q with %+v exp %T`, idx, test.src, test.opts, test.exp)
+
+			got := SourceWith(test.src, test.opts)
+			expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
+			if expTyp != gotTyp {
+				t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+			}
+		}
+	})
+
+	t.Run(`Aggressive`, func(t *testing.T) {
+		type test struct {
+			src        string
+			exp        ast.Node
+			aggressive ast.Node
+		}
+		tests := []test{
+			{"package main\n\nfunc main() {}", astFile, &ast.FuncDecl{}},
+			{"// Package doc.\npackage main\n\nvar x = 1", astFile, astDecl},
+			{"package main\n\nimport \"fmt\"", astFile, astDecl},
+			{"package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }", astFile, astFile},
+			{"package main\n\nfunc a() {}\nfunc b() {}", astFile, astFile},
+			{`package main`, astFile, astFile},
+			{`func f() {}`, &ast.FuncDecl{}, &ast.FuncDecl{}},
+			{`x := 1`, astAssign, astAssign},
+			{`someCall()`, astCall, astCall},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q exp %T aggressive %T`,
+				idx, test.src, test.exp, test.aggressive)
+
+			got := SourceWith(test.src, Options{})
+			expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
+			if expTyp != gotTyp {
+				t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+			}
+
+			got = SourceWith(test.src, Options{Aggressive: true})
+			expTyp, gotTyp = reflect.TypeOf(test.aggressive), reflect.TypeOf(got)
+			if expTyp != gotTyp {
+				t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+			}
+		}
+	})
+
+	t.Run(`ExprViaFile`, func(t *testing.T) {
+		type test struct {
+			src  string
+			exp  ast.Node
+			kind Kind
+			def  ast.Node
+		}
+		tests := []test{
+			{`x`, astExpr, KindExpr, astExpr},
+			{`someCall()`, astCall, KindExpr, astCall},
+			{`[]int{1, 2}`, &ast.CompositeLit{}, KindExpr, &ast.CompositeLit{}},
+			{`x // comment`, astExpr, KindExpr, astExpr},
+
+			// A trailing semicolon terminates the declaration, while ParseExpr
+			// requires EOF so the default climbs to the statement level.
+			{`someCall();`, astCall, KindExpr, &ast.ExprStmt{}},
+			{`x;`, astExpr, KindExpr, &ast.ExprStmt{}},
+
+			// Lists and additional declarations are not a single expression.
+			{`a, b`, &ast.Ident{}, KindPkg, &ast.Ident{}},
+			{"x\nfunc f() {}", &ast.Ident{}, KindPkg, &ast.Ident{}},
+			{`x := 1`, astAssign, KindDecl, astAssign},
+		}
+		opts := Options{ExprViaFile: true}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q exp %T at %v`, idx, test.src, test.exp, test.kind)
+
+			var last Attempt
+			opts.parse(token.NewFileSet(), test.src, func(a Attempt) {
+				last = a
+			})
+
+			got := SourceWith(test.src, opts)
+			expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
+			if expTyp != gotTyp {
+				t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+			}
+			if exp, got := test.kind, last.Kind; exp != got {
+				t.Fatalf(`exp last attempt kind %v; got %v`, exp, got)
+			}
+			if exp, got := reflect.TypeOf(test.def), reflect.TypeOf(Source(test.src)); exp != got {
+				t.Fatalf(`exp Source to return %v; got %v`, exp, got)
+			}
+		}
+
+		exp := "package " + pkgSentinel + "\n\nvar _ = x\n"
+		if got := opts.exprFile(`x`); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	})
+}
+
+func TestSourceWithShebang(t *testing.T) {
+	const script = "#!/usr/bin/env gorun\npackage main\n\nfunc main() {}\n"
+	opts := Options{StripShebang: true}
+
+	type test struct {
+		src string
+		exp ast.Node
+	}
+	tests := []test{
+		{script, astFile},
+		{"#!/usr/bin/env gorun\n\npackage main", astFile},
+		{"#!/bin/sh\nfunc f() {}", &ast.FuncDecl{}},
+		{"#!/bin/sh\nx := 1", astAssign},
+		{"#!/bin/sh\n1 + 2", &ast.BinaryExpr{}},
+		{"#!", astExpr},
+		{"//usr/bin/env go run \"$0\" \"$@\"; exit\npackage main", astFile},
+		{"package main\n\nvar s = \"#!\"", astFile},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T`, idx, test.src, test.exp)
+
+		got := SourceWith(test.src, opts)
+		expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
+		if expTyp != gotTyp {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+		}
+	}
+
+	t.Run(`Disabled`, func(t *testing.T) {
+		if _, err := SourceErr(script); err == nil {
+			t.Fatal(`exp non-nil err from SourceErr without StripShebang`)
+		}
+	})
+	t.Run(`Positions`, func(t *testing.T) {
+		fset := token.NewFileSet()
+		node, err := opts.parse(fset, script, nil)
+		if err != nil {
+			t.Fatalf(`exp nil err from parse; got %v`, err)
+		}
+		fn := node.(*ast.File).Decls[0]
+		if exp, got := `string.go:4:1`, fset.Position(fn.Pos()).String(); exp != got {
+			t.Fatalf(`exp func at %v; got %v`, exp, got)
+		}
+	})
+}
+
+func TestSourceWithOnError(t *testing.T) {
+	const src = "x := \n\t{"
+	t.Run(`ErrorIdent`, func(t *testing.T) {
+		for _, mode := range []ErrorMode{ErrorIdent, ErrorMode(-1), ErrorNil + 1} {
+			id, ok := SourceWith(src, Options{OnError: mode}).(*ast.Ident)
+			if !ok {
+				t.Fatalf(`exp *ast.Ident for mode %v`, mode)
+			}
+			if _, err := SourceErr(src); id.Name != err.Error() {
+				t.Fatalf(`exp ident name %q; got %q`, err.Error(), id.Name)
+			}
+		}
+	})
+	t.Run(`ErrorBadExpr`, func(t *testing.T) {
+		bad, ok := SourceWith(src, Options{OnError: ErrorBadExpr}).(*ast.BadExpr)
+		if !ok {
+			t.Fatal(`exp *ast.BadExpr`)
+		}
+
+		fset := token.NewFileSet()
+		file := fset.AddFile(`string.go`, -1, len(src))
+		if exp, got := 0, file.Offset(bad.From); exp != got {
+			t.Fatalf(`exp From at offset %v; got %v`, exp, got)
+		}
+		if exp, got := len(src), file.Offset(bad.To); exp != got {
+			t.Fatalf(`exp To at offset %v; got %v`, exp, got)
+		}
+		if exp, got := len(src), int(bad.End()-bad.Pos()); exp != got {
+			t.Fatalf(`exp BadExpr to span %v bytes; got %v`, exp, got)
+		}
+	})
+	t.Run(`ErrorNil`, func(t *testing.T) {
+		if node := SourceWith(src, Options{OnError: ErrorNil}); node != nil {
+			t.Fatalf(`exp nil node; got %T`, node)
+		}
+		node, _, err := CheckWith(src, Options{OnError: ErrorNil})
+		if node != nil || err == nil {
+			t.Fatalf(`exp nil node and non-nil err from CheckWith; got %T, %v`, node, err)
+		}
+	})
+	t.Run(`Success`, func(t *testing.T) {
+		for _, mode := range []ErrorMode{ErrorIdent, ErrorBadExpr, ErrorNil} {
+			if _, ok := SourceWith(`x := 1`, Options{OnError: mode}).(*ast.AssignStmt); !ok {
+				t.Fatalf(`exp *ast.AssignStmt for mode %v`, mode)
+			}
+		}
+	})
+}
+
+func TestSourceWithPrefer(t *testing.T) {
+	type test struct {
+		src    string
+		prefer Kind
+		exp    ast.Node
+		kinds  []Kind
+	}
+	tests := []test{
+		{`x`, KindNode, &ast.Ident{}, []Kind{KindExpr}},
+		{`x`, KindExpr, &ast.Ident{}, []Kind{KindExpr}},
+		{`x`, KindDecl, &ast.ExprStmt{}, []Kind{KindDecl}},
+		{`x`, KindStmt, &ast.ExprStmt{}, []Kind{KindStmt}},
+		{`x`, KindPkg, &ast.Ident{}, []Kind{KindPkg, KindExpr}},
+		{`f(42)`, KindDecl, &ast.ExprStmt{}, []Kind{KindDecl}},
+		{`x := 1`, KindExpr, &ast.AssignStmt{}, []Kind{KindExpr, KindDecl}},
+		{`x := 1`, KindFile, &ast.AssignStmt{},
+			[]Kind{KindFile, KindPkg, KindExpr, KindDecl}},
+		{`func f() {}`, KindDecl, &ast.FuncDecl{},
+			[]Kind{KindDecl, KindStmt, KindBlock, KindFile}},
+		{`package p`, KindDecl, &ast.File{},
+			[]Kind{KindDecl, KindStmt, KindBlock, KindFile, KindPkg}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - src %q prefer %v`, idx, test.src, test.prefer)
+
+		var kinds []Kind
+		opts := Options{Prefer: test.prefer}
+		node, err := opts.parse(token.NewFileSet(), test.src, func(a Attempt) {
+			kinds = append(kinds, a.Kind)
+		})
+		if err != nil {
+			t.Fatalf(`exp nil err; got %v`, err)
+		}
+		if exp, got := reflect.TypeOf(test.exp), reflect.TypeOf(node); exp != got {
+			t.Fatalf(`exp node type %v; got %v`, exp, got)
+		}
+		if exp, got := test.kinds, kinds; !reflect.DeepEqual(exp, got) {
+			t.Fatalf(`exp attempts at %v; got %v`, exp, got)
+		}
+		if exp, got := sprint(t, Source(test.src)), sprint(t, SourceWith(test.src, opts)); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Types`, func(t *testing.T) {
+		for prefer := KindNode; prefer <= KindPkg; prefer++ {
+			node := SourceWith(`x`, Options{Prefer: prefer})
+			if stmt, ok := node.(*ast.ExprStmt); ok {
+				node = stmt.X
+			}
+			if id, ok := node.(*ast.Ident); !ok || id.Name != `x` {
+				t.Fatalf(`exp expression x for prefer %v; got %T`, prefer, node)
+			}
+		}
+
+		field, err := SourceField(`x`)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceField; got %v`, err)
+		}
+		if id, ok := field.Type.(*ast.Ident); !ok || id.Name != `x` || len(field.Names) > 0 {
+			t.Fatalf(`exp an unnamed field of type x; got %v`, sprint(t, field.Type))
+		}
+	})
+
+	t.Run(`Errors`, func(t *testing.T) {
+		_, exp := SourceErr(`x :=`)
+		for _, prefer := range []Kind{KindDecl, KindFile, KindPkg} {
+			_, _, got := CheckWith(`x :=`, Options{Prefer: prefer})
+			if got == nil || exp.Error() != got.Error() {
+				t.Fatalf(`exp err %v for prefer %v; got %v`, exp, prefer, got)
+			}
+		}
+		for _, prefer := range []Kind{KindNode - 1, KindPkg + 1} {
+			if _, _, err := CheckWith(`x`, Options{Prefer: prefer}); err == nil {
+				t.Fatalf(`exp non-nil err for prefer %v`, int(prefer))
+			}
+		}
+	})
+}
+
+func TestSourceWithLogger(t *testing.T) {
+	type test struct {
+		src  string
+		exp  []string
+		fail bool
+	}
+	tests := []test{
+		{`x`, []string{
+			"astfrom: Expr attempt succeeded in ", "\tx\n"}, false},
+		{`x := 1`, []string{
+			"astfrom: Expr attempt failed in ", "\tx := 1\n",
+			"astfrom: Decl attempt succeeded in ", "\tfunc " + fnSentinel + " {\n", "\t\tx := 1\n"}, false},
+		{`x :=`, []string{
+			"astfrom: Expr attempt failed in ", "astfrom: Pkg attempt failed in "}, true},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q`, idx, test.src)
+
+		var buf bytes.Buffer
+		_, err := Options{Logger: &buf}.parse(token.NewFileSet(), test.src, nil)
+		if exp, got := test.fail, err != nil; exp != got {
+			t.Fatalf(`exp failure %v; got err %v`, exp, err)
+		}
+
+		got, from := buf.String(), 0
+		for _, exp := range test.exp {
+			at := strings.Index(got[from:], exp)
+			if at < 0 {
+				t.Fatalf("exp %q in order within log:\n%v", exp, got)
+			}
+			from += at + len(exp)
+		}
+	}
+
+	t.Run(`Nil`, func(t *testing.T) {
+		if _, err := (Options{}).parse(token.NewFileSet(), `x := 1`, nil); err != nil {
+			t.Fatalf(`exp nil err without a Logger; got %v`, err)
+		}
+	})
+}
+
+func TestSourceWithImports(t *testing.T) {
+	opts := Options{Imports: []string{`fmt`, `net/http`}}
+
+	type test struct {
+		src string
+		exp ast.Node
+	}
+	tests := []test{
+		{`fmt.Println("hi")`, astCall},
+		{`x := fmt.Sprint(1)`, astAssign},
+		{`var c http.Client`, astDecl},
+		{`func f() { fmt.Println() }`, &ast.FuncDecl{}},
+		{`a := 1; b := 2`, astBlock},
+		{`package main; import "os"`, astFile},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T`, idx, test.src, test.exp)
+
+		got := SourceWith(test.src, opts)
+		expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
+		if expTyp != gotTyp {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+		}
+		if exp := Source(test.src); !Equal(exp, got) {
+			t.Fatalf(`exp node equal to the node parsed without imports`)
+		}
+	}
+
+	t.Run(`Expand`, func(t *testing.T) {
+		exp := "package " + pkgSentinel + "\n\n" +
+			"import (\n\t\"fmt\"\n\t\"net/http\"\n)\n\n" + trgFile
+		if got := opts.expand("", KindExpr, KindPkg); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+		if exp, got := trgFile, opts.expand("", KindExpr, KindFile); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	})
+}
+
+func TestSourceReturn(t *testing.T) {
+	// return is only valid within a function, so it must be parsed within the
+	// sentinel func of a file and reduced back to the *ast.ReturnStmt.
+	node, attempts := SourceTrace(`return x, 1`)
+	if exp, got := KindDecl, attempts[len(attempts)-1].Kind; exp != got {
+		t.Fatalf(`exp return to parse at %v; got %v`, exp, got)
+	}
+	if !strings.Contains(attempts[len(attempts)-1].Src, fnSentinel) {
+		t.Fatalf(`exp return to parse within the sentinel func`)
+	}
+	ret, ok := node.(*ast.ReturnStmt)
+	if !ok {
+		t.Fatalf(`exp *ast.ReturnStmt; got %T`, node)
+	}
+	if exp, got := 2, len(ret.Results); exp != got {
+		t.Fatalf(`exp %v results; got %v`, exp, got)
+	}
+}
+
+func TestSourceClauses(t *testing.T) {
+	// Statements with clauses reduce as a whole, the reduction of the sole
+	// statement of the sentinel func must never reach into its clauses.
+	type test struct {
+		src              string
+		exp              ast.Stmt
+		init, cond, post string
+	}
+	tests := []test{
+		{`if x := f(); x != nil {}`, &ast.IfStmt{}, `x := f()`, `x != nil`, ``},
+		{`if _astfrom := f(); x {}`, &ast.IfStmt{}, `_astfrom := f()`, `x`, ``},
+		{`if _ = f(); x {} else {}`, &ast.IfStmt{}, `_ = f()`, `x`, ``},
+		{`for i := 0; i < n; i++ {}`, &ast.ForStmt{}, `i := 0`, `i < n`, `i++`},
+		{`for _astfrom = 0; ; _astfrom = 1 {}`, &ast.ForStmt{}, `_astfrom = 0`, ``, `_astfrom = 1`},
+		{`for ; x; {}`, &ast.ForStmt{}, ``, `x`, ``},
+		{`for x := range f() {}`, &ast.RangeStmt{}, ``, `f()`, ``},
+		{`switch x := f(); x {}`, &ast.SwitchStmt{}, `x := f()`, `x`, ``},
+		{`switch _astfrom := x.(type) {}`, &ast.TypeSwitchStmt{}, ``, `_astfrom := x.(type)`, ``},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T`, idx, test.src, test.exp)
+
+		for _, opts := range []Options{{}, {KeepAssign: true}, {Aggressive: true}} {
+			node := SourceWith(test.src, opts)
+			if exp, got := reflect.TypeOf(test.exp), reflect.TypeOf(node); exp != got {
+				t.Fatalf(`exp %v with %+v; got %v`, exp, opts, got)
+			}
+
+			var init, cond, post ast.Node
+			switch T := node.(type) {
+			case *ast.IfStmt:
+				init, cond = T.Init, T.Cond
+			case *ast.ForStmt:
+				init, cond, post = T.Init, T.Cond, T.Post
+			case *ast.RangeStmt:
+				cond = T.X
+			case *ast.SwitchStmt:
+				init, cond = T.Init, T.Tag
+			case *ast.TypeSwitchStmt:
+				init, cond = T.Init, T.Assign
+			}
+			if exp, got := test.init, sprint(t, init); exp != got {
+				t.Fatalf(`exp init %q; got %q`, exp, got)
+			}
+			if exp, got := test.cond, sprint(t, cond); exp != got {
+				t.Fatalf(`exp cond %q; got %q`, exp, got)
+			}
+			if exp, got := test.post, sprint(t, post); exp != got {
+				t.Fatalf(`exp post %q; got %q`, exp, got)
+			}
+		}
+	}
+}
+
+func TestSourceRange(t *testing.T) {
+	type test struct {
+		src        string
+		key, value string
+		tok        token.Token
+		x          string
+	}
+	tests := []test{
+		{`for k, v := range m {}`, `k`, `v`, token.DEFINE, `m`},
+		{`for k := range m {}`, `k`, ``, token.DEFINE, `m`},
+		{`for _, v := range xs { use(v) }`, `_`, `v`, token.DEFINE, `xs`},
+		{`for k, v = range m {}`, `k`, `v`, token.ASSIGN, `m`},
+		{`for _astfrom = range m {}`, `_astfrom`, ``, token.ASSIGN, `m`},
+		{`for range ch {}`, ``, ``, token.ILLEGAL, `ch`},
+		{`for i := range 10 {}`, `i`, ``, token.DEFINE, `10`},
+		{`for range n {}`, ``, ``, token.ILLEGAL, `n`},
+		{`for i := range len(xs) - 1 {}`, `i`, ``, token.DEFINE, `len(xs) - 1`},
+		{`for k, v := range maps.All(m) {}`, `k`, `v`, token.DEFINE, `maps.All(m)`},
+		{"for v := range func(yield func(int) bool) {\n\tyield(1)\n} {\n\tuse(v)\n}",
+			`v`, ``, token.DEFINE, "func(yield func(int) bool) {\n\tyield(1)\n}"},
+		{`for x := range seq { break }`, `x`, ``, token.DEFINE, `seq`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q`, idx, test.src)
+
+		for _, opts := range []Options{{}, {KeepAssign: true}, {Aggressive: true}} {
+			node := SourceWith(test.src, opts)
+			rs, ok := node.(*ast.RangeStmt)
+			if !ok {
+				t.Fatalf(`exp *ast.RangeStmt with %+v; got %T`, opts, node)
+			}
+			if exp, got := test.key, sprint(t, rs.Key); exp != got {
+				t.Fatalf(`exp key %q; got %q`, exp, got)
+			}
+			if exp, got := test.value, sprint(t, rs.Value); exp != got {
+				t.Fatalf(`exp value %q; got %q`, exp, got)
+			}
+			if exp, got := test.tok, rs.Tok; exp != got {
+				t.Fatalf(`exp tok %v; got %v`, exp, got)
+			}
+			if exp, got := test.x, sprint(t, rs.X); exp != got {
+				t.Fatalf(`exp x %q; got %q`, exp, got)
+			}
+			if rs.Body == nil {
+				t.Fatal(`exp non-nil body`)
+			}
+		}
+	}
+}
+
+func TestSourceTypeAssert(t *testing.T) {
+	type test struct {
+		src string
+		exp ast.Node
+	}
+	tests := []test{
+		{`x.(int)`, &ast.TypeAssertExpr{}},
+		{`x.(*pkg.T)`, &ast.TypeAssertExpr{}},
+		{`x.(interface{ M() })`, &ast.TypeAssertExpr{}},
+		{`v, ok := x.(int)`, &ast.AssignStmt{}},
+		{`int(x)`, &ast.CallExpr{}},
+		{`[]byte(s)`, &ast.CallExpr{}},
+		{`(*T)(p)`, &ast.CallExpr{}},
+		{`(func())(f)`, &ast.CallExpr{}},
+		{`switch x.(type) {}`, &ast.TypeSwitchStmt{}},
+		{`switch v := x.(type) { case int: use(v) }`, &ast.TypeSwitchStmt{}},
+		{`switch v := x.(type) { case any: _ = v.(int) }`, &ast.TypeSwitchStmt{}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q`, idx, test.src)
+
+		node, err := SourceErr(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err; got %v`, err)
+		}
+		if exp, got := reflect.TypeOf(test.exp), reflect.TypeOf(node); exp != got {
+			t.Fatalf(`exp node type %v; got %v`, exp, got)
+		}
+		if _, ok := node.(ast.Expr); !ok {
+			continue
+		}
+		if exp, got := test.src, sprint(t, node); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Guard`, func(t *testing.T) {
+		type test struct {
+			src string
+			exp string
+		}
+		tests := []test{
+			{`x.(type)`, `1:1: use of x.(type) outside type switch`},
+			{`f(x.(type))`, `1:3: use of x.(type) outside type switch`},
+			{`a.b.(type)`, `1:1: use of a.b.(type) outside type switch`},
+			{`v := x.(type)`, `1:6: use of x.(type) outside type switch`},
+			{`var v = x.(type)`, `1:9: use of x.(type) outside type switch`},
+			{"\n\tswitch v := x.(type) {\n\tcase any:\n\t\t_ = v.(type)\n\t}",
+				`4:7: use of v.(type) outside type switch`},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q`, idx, test.src)
+
+			node, err := SourceErr(test.src)
+			if err == nil {
+				t.Fatalf(`exp non-nil err; got %T`, node)
+			}
+			if exp, got := test.exp, err.Error(); exp != got {
+				t.Fatalf(`exp err %q; got %q`, exp, got)
+			}
+			if id, ok := node.(*ast.Ident); !ok || id.Name != err.Error() {
+				t.Fatalf(`exp *ast.Ident holding the err; got %T`, node)
+			}
+		}
+	})
+}
+
+func TestSourceStmt(t *testing.T) {
+	type test struct {
+		src string
+		exp ast.Stmt
+		fmt string
+	}
+	tests := []test{
+		{`x := 1`, astAssign, `x := 1`},
+		{`if c {}`, astStmt, "if c {\n}"},
+		{`f()`, &ast.ExprStmt{}, `f()`},
+		{`<-ch`, &ast.ExprStmt{}, `<-ch`},
+		{`x`, astAssign, `_ = x`},
+		{`1 + 2`, astAssign, `_ = 1 + 2`},
+		{`var x int`, &ast.DeclStmt{}, `var x int`},
+		{`return x`, &ast.ReturnStmt{}, `return x`},
+		{`a := 1; b := 2`, astBlock, "{\n\ta := 1\n\tb := 2\n}"},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T`, idx, test.src, test.exp)
+
+		got, err := SourceStmt(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceStmt; got %v`, err)
+		}
+		expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
+		if expTyp != gotTyp {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+		}
+		if exp, got := test.fmt, sprint(t, got); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{`package main`, `func f() {}`, `{`} {
+			got, err := SourceStmt(src)
+			if err == nil {
+				t.Fatalf(`exp non-nil err from SourceStmt(%q); got %T`, src, got)
+			}
+			if got != nil {
+				t.Fatalf(`exp nil stmt from SourceStmt(%q); got %T`, src, got)
+			}
+		}
+	})
+}
+
+func TestSourceBraces(t *testing.T) {
+	type test struct {
+		src  string
+		exp  ast.Node
+		elts int
+	}
+	tests := []test{
+		// composite literals
+		{`{1, 2}`, &ast.CompositeLit{}, 2},
+		{`{1, 2,}`, &ast.CompositeLit{}, 2},
+		{`{x: 1}`, &ast.CompositeLit{}, 1},
+		{`{x: 1, y: 2}`, &ast.CompositeLit{}, 2},
+		{`{"a": f()}`, &ast.CompositeLit{}, 1},
+		{`{x}`, &ast.CompositeLit{}, 1},
+		{`{1}`, &ast.CompositeLit{}, 1},
+		{`{{1}, {2, 3}}`, &ast.CompositeLit{}, 2},
+		{`{1, 2} // comment`, &ast.CompositeLit{}, 2},
+		{"{\n\t1,\n\t2,\n}", &ast.CompositeLit{}, 2},
+
+		// blocks
+		{`{}`, &ast.BlockStmt{}, 0},
+		{`{ x := 1 }`, astAssign, 0},
+		{`{ f() }`, &ast.ExprStmt{}, 0},
+		{`{ <-ch }`, &ast.ExprStmt{}, 0},
+		{`{ f(); x }`, &ast.BlockStmt{}, 0},
+		{`{ x: for {} }`, &ast.LabeledStmt{}, 0},
+		{"{\n\tx := 1\n\t_ = x\n}", &ast.BlockStmt{}, 0},
+		{`{1}; {2}`, &ast.BlockStmt{}, 0},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T`, idx, test.src, test.exp)
+
+		got, err := SourceErr(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceErr; got %v`, err)
+		}
+		expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
+		if expTyp != gotTyp {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+		}
+		if lit, ok := got.(*ast.CompositeLit); ok {
+			if lit.Type != nil {
+				t.Fatalf(`exp elided composite literal type; got %T`, lit.Type)
+			}
+			if exp, got := test.elts, len(lit.Elts); exp != got {
+				t.Fatalf(`exp %v elements; got %v`, exp, got)
+			}
+		}
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	type test struct {
+		src  string
+		bare string
+		kind Kind
 	}
 	tests := []test{
-		{astExpr, astExpr, `foo`},
-		{astExpr, astExpr, "myIdent"},
-		{astExpr, astExpr, `int64`},
-		{astLit, astLit, "42"},
-		{astCall, astCall, "myIdent()"},
-		{astFile, astAssign, "foo := 42"},
-		{astFile, astAssign, "{ foo := 42 }"},
-		{astFile, astDecl, "type foo string"},
-		{astFile, astDecl, `const int = 5`},
-		{astFile, astStmt, `if true {};`},
-		{astFile, astBlock, `{ var i int64 = 10; s := i+1 };`},
-		{astFile, astFile, `package main;`},
-		{astFile, astFile,
-			`// Package p
-			package p
-			import "fmt"; func f() { fmt.Println("Hello, World!") };`},
+		{`x // trailing`, `x`, KindExpr},
+		{`someCall() // trailing`, `someCall()`, KindExpr},
+		{`x /* trailing */`, `x`, KindExpr},
+		{"x // trailing\n", `x`, KindExpr},
+		{`x := 1 // trailing`, `x := 1`, KindDecl},
+		{`x := 1 /* trailing */`, `x := 1`, KindDecl},
+		{`if x { y() } // trailing`, `if x { y() }`, KindDecl},
+		{"a := 1\nb := 2 // trailing", "a := 1\nb := 2", KindDecl},
+		{`var x = 1 // trailing`, `var x = 1`, KindDecl},
+		{`type T int // trailing`, `type T int`, KindDecl},
+		{`func f() {} // trailing`, `func f() {}`, KindFile},
+		{"// Doc.\nfunc f() {}", `func f() {}`, KindFile},
+		{"package p\n\nvar x = 1 // trailing", "package p\n\nvar x = 1", KindPkg},
 	}
 	for idx, test := range tests {
-		t.Logf(`test #%va - from src %q exp %[3]T`, idx, test.src, test.exp)
+		for _, mode := range []parser.Mode{0, parser.ParseComments} {
+			t.Logf(`test #%v - from src %q with mode %v exp %v`, idx, test.src, mode, test.kind)
 
-		got, err := source(test.src)
-		if err != nil {
-			t.Fatalf(`exp nil err from source; got %v`, err)
+			opts := Options{Mode: mode}
+			var last Attempt
+			got, err := opts.parse(token.NewFileSet(), test.src, func(a Attempt) {
+				last = a
+			})
+			if err != nil {
+				t.Fatalf(`exp nil err from parse; got %v`, err)
+			}
+			if exp, got := test.kind, last.Kind; exp != got {
+				t.Fatalf(`exp kind %v; got %v`, exp, got)
+			}
+			exp := Source(test.bare)
+			if mode == 0 && !Equal(exp, got) {
+				t.Fatalf("exp node equal to the comment-free node:\n%v", Diff(exp, got))
+			}
+			if exp, got := reflect.TypeOf(exp), reflect.TypeOf(got); exp != got {
+				t.Fatalf(`exp node of type %v; got %v`, exp, got)
+			}
+		}
+	}
+
+	t.Run(`Attached`, func(t *testing.T) {
+		opts := Options{Mode: parser.ParseComments}
+
+		decl := SourceWith(`var x = 1 // trailing`, opts).(*ast.GenDecl)
+		if c := decl.Specs[0].(*ast.ValueSpec).Comment; c == nil || c.Text() != "trailing\n" {
+			t.Fatalf(`exp trailing comment on spec; got %v`, c)
+		}
+
+		fn := SourceWith("// Doc.\nfunc f() {}", opts).(*ast.FuncDecl)
+		if fn.Doc == nil || fn.Doc.Text() != "Doc.\n" {
+			t.Fatalf(`exp doc comment on func; got %v`, fn.Doc)
+		}
+
+		st := SourceWith("struct {\n\tX int // x\n}", opts).(*ast.StructType)
+		if c := st.Fields.List[0].Comment; c == nil || c.Text() != "x\n" {
+			t.Fatalf(`exp trailing comment on field; got %v`, c)
+		}
+
+		decl = SourceWith(`var x = 1 // trailing`, Options{}).(*ast.GenDecl)
+		if c := decl.Specs[0].(*ast.ValueSpec).Comment; c != nil {
+			t.Fatalf(`exp no comments without ParseComments; got %v`, c.Text())
+		}
+	})
+}
+
+func TestSourceMode(t *testing.T) {
+	t.Run(`Unsupported`, func(t *testing.T) {
+		for _, mode := range []parser.Mode{
+			parser.PackageClauseOnly,
+			parser.ImportsOnly,
+			parser.DeclarationErrors,
+			parser.ParseComments | parser.ImportsOnly,
+		} {
+			opts := Options{Mode: mode}
+			if _, err := opts.parse(token.NewFileSet(), `x := 1`, nil); err == nil {
+				t.Fatalf(`exp non-nil err for mode %v`, mode)
+			}
+			if _, ok := SourceWith(`x := 1`, opts).(*ast.Ident); !ok {
+				t.Fatalf(`exp error ident for mode %v`, mode)
+			}
+			if _, _, err := CheckWith(`x := 1`, opts); err == nil {
+				t.Fatalf(`exp non-nil err from CheckWith for mode %v`, mode)
+			}
+		}
+	})
+	t.Run(`CommentsWithoutObjects`, func(t *testing.T) {
+		type test struct {
+			src     string
+			comment func(ast.Node) *ast.CommentGroup
+			ident   func(ast.Node) *ast.Ident
+		}
+		tests := []test{
+			{
+				"var x = 1 // c",
+				func(n ast.Node) *ast.CommentGroup { return n.(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Comment },
+				func(n ast.Node) *ast.Ident { return n.(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Names[0] },
+			},
+			{
+				"// c\nfunc f(x int) { _ = x }",
+				func(n ast.Node) *ast.CommentGroup { return n.(*ast.FuncDecl).Doc },
+				func(n ast.Node) *ast.Ident { return n.(*ast.FuncDecl).Type.Params.List[0].Names[0] },
+			},
+			{
+				"type T struct {\n\tx T // c\n}",
+				func(n ast.Node) *ast.CommentGroup {
+					return n.(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType).Fields.List[0].Comment
+				},
+				func(n ast.Node) *ast.Ident {
+					return n.(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType).Fields.List[0].Type.(*ast.Ident)
+				},
+			},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q`, idx, test.src)
+
+			node := SourceWith(test.src, Options{Mode: parser.ParseComments | parser.SkipObjectResolution})
+			if c := test.comment(node); c == nil || c.Text() != "c\n" {
+				t.Fatalf(`exp comment to survive reduction; got %v`, c)
+			}
+			if obj := test.ident(node).Obj; obj != nil {
+				t.Fatalf(`exp nil Obj with SkipObjectResolution; got %v`, obj)
+			}
+
+			node = SourceWith(test.src, Options{})
+			if c := test.comment(node); c != nil {
+				t.Fatalf(`exp no comment without ParseComments; got %v`, c.Text())
+			}
+			if test.ident(node).Obj == nil {
+				t.Fatal(`exp non-nil Obj without SkipObjectResolution`)
+			}
 		}
+	})
+}
+
+func TestSourceNesting(t *testing.T) {
+	nest := func(open, mid, close string, n int) string {
+		return strings.Repeat(open, n) + mid + strings.Repeat(close, n)
+	}
+
+	type test struct {
+		src string
+		ok  bool
+	}
+	tests := []test{
+		{nest(`(`, `x`, `)`, 100), true},
+		{nest(`(`, `x`, `)`, maxNesting), true},
+		{nest(`[]T{`, `x`, `}`, 100), true},
+		{nest(`{`, `f()`, `}`, 100), true},
+		{nest(`(`, `x`, `)`, maxNesting+1), false},
+		{nest(`(`, `x`, `)`, 100000), false},
+		{nest(`(`, `x`, ``, 100000), false},
+		{nest(`[]T{`, `x`, `}`, 100000), false},
+		{nest(`{`, `f()`, `}`, 100000), false},
+		{nest(`f(`, `x`, `)`, 100000), false},
+		{`"` + strings.Repeat(`(`, 100000) + `"`, true},
+		{"/* " + strings.Repeat(`{`, 100000) + " */ x", true},
+		{strings.Repeat(`f(x); `, maxNesting+1), true},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src of length %v exp ok %v`, idx, len(test.src), test.ok)
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := SourceErr(test.src)
+			done <- err
+		}()
+
+		var err error
+		select {
+		case err = <-done:
+		case <-time.After(time.Second * 10):
+			t.Fatal(`exp SourceErr to return within 10s`)
+		}
+		if exp, got := test.ok, err == nil; exp != got {
+			t.Fatalf(`exp ok %v; got err %v`, exp, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), `maximum nesting depth`) {
+			t.Fatalf(`exp nesting error; got %v`, err)
+		}
+	}
+
+	t.Run(`Trace`, func(t *testing.T) {
+		_, attempts := SourceTrace(nest(`(`, `x`, `)`, maxNesting+1))
+		if exp, got := 1, len(attempts); exp != got {
+			t.Fatalf(`exp %v attempt; got %v`, exp, got)
+		}
+		if a := attempts[0]; a.Kind != KindExpr || a.Err == nil {
+			t.Fatalf(`exp failed Expr attempt; got %v attempt with err %v`, a.Kind, a.Err)
+		}
+		if _, err := SourceErr(nest(`(`, `x`, `)`, maxNesting+1)); !errors.Is(err, ErrUnparseable) {
+			t.Fatalf(`exp ErrUnparseable; got %v`, err)
+		}
+	})
+}
+
+func TestSourceEmpty(t *testing.T) {
+	for idx, src := range []string{"", "   ", "\t", "\n\n", " \t\r\n "} {
+		t.Logf(`test #%v - from src %q exp blank ident`, idx, src)
+
+		id, ok := Source(src).(*ast.Ident)
+		if !ok {
+			t.Fatalf(`exp *ast.Ident; got %T`, Source(src))
+		}
+		if exp, got := "_", id.Name; exp != got {
+			t.Fatalf(`exp ident named %q; got %q`, exp, got)
+		}
+	}
+}
+
+func TestSourceUnicode(t *testing.T) {
+	type test struct {
+		src string
+		exp ast.Node
+		fmt string
+	}
+	tests := []test{
+		{`café`, astExpr, `café`},
+		{`café := 1`, astAssign, `café := 1`},
+		{`日本語 := "😀"`, astAssign, `日本語 := "😀"`},
+		{`s := "héllo, 世界 👋"`, astAssign, `s := "héllo, 世界 👋"`},
+		{`r := '世'`, astAssign, `r := '世'`},
+		{"\n\n\tπ := 3.14 // ≈ π\n\n", astAssign, `π := 3.14`},
+		{`func Ünïcödé() string { return "✓" }`, &ast.FuncDecl{},
+			"func Ünïcödé() string {\n\treturn \"✓\"\n}"},
+		{"package größe\n\nvar ß = `ü`", astFile,
+			"package größe\n\nvar ß = `ü`\n"},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T`, idx, test.src, test.exp)
 
+		got, err := SourceErr(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceErr; got %v`, err)
+		}
 		expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
 		if expTyp != gotTyp {
 			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
 		}
+		if exp, got := test.fmt, sprint(t, got); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
 
-		t.Logf(`test #%vb - from src %q exp %[3]T`, idx, test.src, test.expReduce)
-		got = reduce(got)
-		expTyp, gotTyp = reflect.TypeOf(test.expReduce), reflect.TypeOf(got)
+	t.Run(`Error`, func(t *testing.T) {
+		node, err := SourceErr(`café := "😀`)
+		if err == nil {
+			t.Fatal(`exp non-nil err from SourceErr`)
+		}
+		id, ok := node.(*ast.Ident)
+		if !ok {
+			t.Fatalf(`exp *ast.Ident from SourceErr; got %T`, node)
+		}
+		if !utf8.ValidString(id.Name) {
+			t.Fatalf(`exp valid utf8 in error ident; got %q`, id.Name)
+		}
+		if exp, got := id.Name, sprint(t, id); exp != got {
+			t.Fatalf(`exp error ident to format as %q; got %q`, exp, got)
+		}
+	})
+	t.Run(`Placeholder`, func(t *testing.T) {
+		node := SourceWith(`§名前 := §値`, Options{Placeholder: '§'})
+		holes := Holes(node)
+		if exp, got := 2, len(holes); exp != got {
+			t.Fatalf(`exp %v holes; got %v`, exp, got)
+		}
+		if _, ok := holes[`名前`]; !ok {
+			t.Fatalf(`exp hole named 名前; got %v`, holes)
+		}
+	})
+}
+
+func TestSourceUnusual(t *testing.T) {
+	type test struct {
+		src string
+		exp ast.Node
+		err bool
+	}
+	tests := []test{
+		{"// #include <stdio.h>\nimport \"C\"", astDecl, false},
+		{"/*\n#include <stdlib.h>\n*/\nimport \"C\"\n\nfunc F() { C.free(nil) }",
+			astDecl, false},
+		{"package main\n\n// #cgo LDFLAGS: -lm\nimport \"C\"", astFile, false},
+		{"//export\nfunc F() {}", &ast.FuncDecl{}, false},
+		{"//export F\nfunc F() {}", &ast.FuncDecl{}, false},
+		{"//go:build linux\n\npackage p", astFile, false},
+		{"// hi", astExpr, false},
+		{"/* a */\n\n// b\n", astExpr, false},
+		{"/* unterminated", astExpr, true},
+		{"TEXT ·Add(SB),NOSPLIT,$0\n\tMOVQ a+0(FP), AX\n\tRET", astExpr, true},
+		{"SELECT * FROM t WHERE id = 1;", astExpr, true},
+		{"<html><body>hi</body></html>", astExpr, true},
+		{"#!/bin/sh\necho hi", astExpr, true},
+		{"\x00\xff\xfe", astExpr, true},
+		{"}}}{{{", astExpr, true},
+		{strings.Repeat("{", 1000), astExpr, true},
+		{strings.Repeat("f(", 1000), astExpr, true},
+		{strings.Repeat("x + ", 5000) + "x", &ast.BinaryExpr{}, false},
+	}
+	for idx, test := range tests {
+		src := test.src
+		if len(src) > 64 {
+			src = src[:64] + `...`
+		}
+		t.Logf(`test #%v - from src %q exp %T`, idx, src, test.exp)
+
+		type result struct {
+			node ast.Node
+			err  error
+		}
+		ch := make(chan result, 1)
+		go func() {
+			node, err := SourceErr(test.src)
+			ch <- result{node, err}
+		}()
+
+		var res result
+		select {
+		case res = <-ch:
+		case <-time.After(time.Second * 5):
+			t.Fatal(`exp SourceErr to return promptly`)
+		}
+		if exp, got := test.err, res.err != nil; exp != got {
+			t.Fatalf(`exp err %v from SourceErr; got %v`, exp, res.err)
+		}
+		expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(res.node)
 		if expTyp != gotTyp {
 			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
 		}
+	}
+}
+
+func TestSourceBlankLines(t *testing.T) {
+	type test struct {
+		src, exp string
+	}
+	tests := []test{
+		{"\n\n  x := 1  \n\n", `x := 1`},
+		{"\n\tfoo()\n", `foo()`},
+		{" \t\n\r\n42 \n", `42`},
+		{"\n\nif x {\n\n\ty()\n}\n\n", "if x {\n\n\ty()\n}"},
+		{"\n\n  var a = 1\n\n  var b = 2\n\n", "var a = 1\n\n  var b = 2"},
+		{"\n\npackage p\n\nfunc f() {}\n\n", "package p\n\nfunc f() {}"},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp equal to %q`, idx, test.src, test.exp)
+
+		got, gotAttempts := SourceTrace(test.src)
+		exp, expAttempts := SourceTrace(test.exp)
+		if !equal(exp, got) {
+			t.Fatalf("\n---- [exp] ----\n%#v\n\n---- [got] ----\n%#v\n", exp, got)
+		}
+		if exp, got := len(expAttempts), len(gotAttempts); exp != got {
+			t.Fatalf(`exp %v attempts; got %v`, exp, got)
+		}
+	}
+}
+
+func TestTrimLines(t *testing.T) {
+	type test struct {
+		src, exp string
+	}
+	tests := []test{
+		{"", ""},
+		{"x", "x"},
+		{"  x", "  x"},
+		{"\n  x", "  x"},
+		{" \n\t\n  x \n \n", "  x"},
+		{"a\n\n\nb", "a\n\n\nb"},
+		{"\n\na\n\n\nb\n\n", "a\n\n\nb"},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.exp)
+		if exp, got := test.exp, trimLines(test.src); exp != got {
+			t.Fatalf(`exp trimLines to return %q; got %q`, exp, got)
+		}
+	}
+}
+
+func TestSourceTrace(t *testing.T) {
+	type test struct {
+		src string
+		exp Kind
+		n   int
+	}
+	tests := []test{
+		{`foo`, KindExpr, 1},
+		{`foo := 42`, KindDecl, 2},
+		{`func f() {}`, KindFile, 5},
+		{`package main;`, KindPkg, 6},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		node, attempts := SourceTrace(test.src)
+		if node == nil {
+			t.Fatal(`exp non-nil node from SourceTrace`)
+		}
+		if exp, got := test.n, len(attempts); exp != got {
+			t.Fatalf(`exp %v attempts; got %v`, exp, got)
+		}
+		last := attempts[len(attempts)-1]
+		if last.Err != nil {
+			t.Fatalf(`exp nil err from last attempt; got %v`, last.Err)
+		}
+		if exp, got := test.exp, last.Kind; exp != got {
+			t.Fatalf(`exp last attempt kind %v; got %v`, exp, got)
+		}
+		for _, a := range attempts[:len(attempts)-1] {
+			if a.Err == nil {
+				t.Fatalf(`exp non-nil err from attempt %v`, a.Kind)
+			}
+		}
+	}
+
+	node, attempts := SourceTrace(`{`)
+	if _, ok := node.(*ast.Ident); !ok {
+		t.Fatalf(`exp *ast.Ident for unparseable src; got %T`, node)
+	}
+	if exp, got := 6, len(attempts); exp != got {
+		t.Fatalf(`exp %v attempts; got %v`, exp, got)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	type test struct {
+		from, to Kind
+		src      string
+		exp      string
+	}
+	tests := []test{
+		{KindExpr, KindDecl, "_", trgDecl},
+		{KindExpr, KindPkg, "_", trgPkg},
+		{KindDecl, KindFile, trgDecl, trgFile},
+		{KindNode, KindDecl, "_", trgDecl},
+		{KindFile, KindPkg + 1, trgFile, trgPkg},
+
+		// equal
+		{KindExpr, KindExpr, "", ""},
+		{KindExpr, KindExpr, "foo := 42", "foo := 42"},
+		{KindStmt, KindStmt, trgStmt, trgStmt},
+		{KindPkg, KindPkg, trgPkg, trgPkg},
+
+		// inverted
+		{KindDecl, KindExpr, "", ""},
+		{KindPkg, KindExpr, "_", "_"},
+		{KindFile, KindStmt, trgFile, trgFile},
+		{KindPkg + 1, KindPkg, trgPkg, trgPkg},
+		{KindPkg, KindNode - 1, "x", "x"},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - for from %v to %v with src %q`,
+			idx, test.from, test.to, test.src)
 
+		if exp, got := test.exp, Expand(test.src, test.from, test.to); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
 	}
 }
 
 func TestHeuristics(t *testing.T) {
 	type test struct {
-		from, to target
+		from, to Kind
 		src      string
 		exp      string
 	}
 	tests := []test{
-		{targetExpr, targetExpr, "", "_"},
-		{targetExpr, targetExpr, "myIdent", "myIdent"},
-		{targetExpr, targetExpr, "42", "42"},
-		{targetExpr, targetExpr, "myIdent()", "myIdent()"},
-		{targetExpr, targetExpr, "myPkg.myIdent", "myPkg.myIdent"},
-		{targetExpr, targetExpr, "foo := 42", "foo := 42"},
-		{targetExpr, targetDecl, "", "_ = _"},
-		{targetDecl, targetDecl, "_ = myIdent", "_ = myIdent"},
-		{targetExpr, targetDecl, "_", trgDecl},
-		{targetDecl, targetDecl, trgDecl, trgDecl},
-		{targetExpr, targetStmt, "", trgStmt},
-		{targetDecl, targetStmt, trgDecl, trgStmt},
-		{targetStmt, targetStmt, trgStmt, trgStmt},
-		{targetExpr, targetBlock, "", trgBlock},
-		{targetDecl, targetBlock, trgDecl, trgBlock},
-		{targetStmt, targetBlock, trgStmt, trgBlock},
-		{targetBlock, targetBlock, trgBlock, trgBlock},
-		{targetExpr, targetFile, "", trgFile},
-		{targetDecl, targetFile, trgDecl, trgFile},
-		{targetStmt, targetFile, trgStmt, trgFile},
-		{targetBlock, targetFile, trgBlock, trgFile},
-		{targetFile, targetFile, trgFile, trgFile},
-		{targetExpr, targetPkg, "", trgPkg},
-		{targetDecl, targetPkg, trgDecl, trgPkg},
-		{targetStmt, targetPkg, trgStmt, trgPkg},
-		{targetBlock, targetPkg, trgBlock, trgPkg},
-		{targetFile, targetPkg, trgFile, trgPkg},
-		{targetPkg, targetPkg, trgPkg, trgPkg},
+		{KindExpr, KindExpr, "", "_"},
+		{KindExpr, KindExpr, "myIdent", "myIdent"},
+		{KindExpr, KindExpr, "42", "42"},
+		{KindExpr, KindExpr, "myIdent()", "myIdent()"},
+		{KindExpr, KindExpr, "myPkg.myIdent", "myPkg.myIdent"},
+		{KindExpr, KindExpr, "foo := 42", "foo := 42"},
+		{KindExpr, KindDecl, "", "_astfrom = _"},
+		{KindDecl, KindDecl, "_ = myIdent", "_ = myIdent"},
+		{KindExpr, KindDecl, "_", trgDecl},
+		{KindDecl, KindDecl, trgDecl, trgDecl},
+		{KindExpr, KindStmt, "", trgStmt},
+		{KindDecl, KindStmt, trgDecl, trgStmt},
+		{KindStmt, KindStmt, trgStmt, trgStmt},
+		{KindExpr, KindBlock, "", trgBlock},
+		{KindDecl, KindBlock, trgDecl, trgBlock},
+		{KindStmt, KindBlock, trgStmt, trgBlock},
+		{KindBlock, KindBlock, trgBlock, trgBlock},
+		{KindExpr, KindFile, "", trgFile},
+		{KindDecl, KindFile, trgDecl, trgFile},
+		{KindStmt, KindFile, trgStmt, trgFile},
+		{KindBlock, KindFile, trgBlock, trgFile},
+		{KindFile, KindFile, trgFile, trgFile},
+		{KindExpr, KindPkg, "", trgPkg},
+		{KindDecl, KindPkg, trgDecl, trgPkg},
+		{KindStmt, KindPkg, trgStmt, trgPkg},
+		{KindBlock, KindPkg, trgBlock, trgPkg},
+		{KindFile, KindPkg, trgFile, trgPkg},
+		{KindPkg, KindPkg, trgPkg, trgPkg},
 	}
 	for idx, test := range tests {
 		t.Logf(`test #%v - for from %v to %v with src %q`,
@@ -139,29 +1450,82 @@ func TestHeuristics(t *testing.T) {
 	}
 }
 
-func TestTarget(t *testing.T) {
+func TestKind(t *testing.T) {
 	t.Run(`String`, func(t *testing.T) {
 		type test struct {
-			trg target
+			trg Kind
 			exp string
 		}
 		tests := []test{
-			{targetNode, "Node"},
-			{targetBlock, "Block"},
-			{targetDecl, "Decl"},
-			{targetExpr, "Expr"},
-			{targetFile, "File"},
-			{targetPkg, "Pkg"},
-			{targetStmt, "Stmt"},
+			{KindNode, "Node"},
+			{KindBlock, "Block"},
+			{KindDecl, "Decl"},
+			{KindExpr, "Expr"},
+			{KindFile, "File"},
+			{KindPkg, "Pkg"},
+			{KindStmt, "Stmt"},
 
 			// oob/ob1
-			{targetNode - 1, "Node"}, {targetNode - 2, "Node"},
-			{targetPkg + 1, "Node"}, {targetPkg + 2, "Node"},
+			{KindNode - 1, "Node"}, {KindNode - 2, "Node"},
+			{KindPkg + 1, "Node"}, {KindPkg + 2, "Node"},
 		}
 		for idx, test := range tests {
 			t.Logf(`test #%v - exp %v from node %d (%[3]v)`, idx, test.exp, test.trg)
 			if exp, got := test.exp, test.trg.String(); exp != got {
-				t.Fatalf(`exp Target String() to return %q; got %q`, exp, got)
+				t.Fatalf(`exp Kind String() to return %q; got %q`, exp, got)
+			}
+		}
+	})
+	t.Run(`NextPrev`, func(t *testing.T) {
+		type test struct {
+			trg, next, prev Kind
+		}
+		tests := []test{
+			{KindNode, KindExpr, KindNode},
+			{KindExpr, KindDecl, KindNode},
+			{KindDecl, KindStmt, KindExpr},
+			{KindStmt, KindBlock, KindDecl},
+			{KindBlock, KindFile, KindStmt},
+			{KindFile, KindPkg, KindBlock},
+			{KindPkg, KindPkg, KindFile},
+
+			// oob/ob1
+			{KindNode - 1, KindNode, KindNode}, {KindNode - 2, KindNode, KindNode},
+			{KindPkg + 1, KindPkg, KindPkg}, {KindPkg + 2, KindPkg, KindPkg},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - exp next %v and prev %v from kind %d`,
+				idx, test.next, test.prev, test.trg)
+			if exp, got := test.next, test.trg.Next(); exp != got {
+				t.Fatalf(`exp Kind Next() to return %v; got %v`, exp, got)
+			}
+			if exp, got := test.prev, test.trg.Prev(); exp != got {
+				t.Fatalf(`exp Kind Prev() to return %v; got %v`, exp, got)
+			}
+		}
+	})
+	t.Run(`Between`, func(t *testing.T) {
+		type test struct {
+			trg, a, b Kind
+			exp       bool
+		}
+		tests := []test{
+			{KindExpr, KindExpr, KindExpr, true},
+			{KindExpr, KindNode, KindPkg, true},
+			{KindStmt, KindDecl, KindBlock, true},
+			{KindStmt, KindBlock, KindDecl, true},
+			{KindNode, KindNode, KindPkg, true},
+			{KindPkg, KindNode, KindPkg, true},
+			{KindExpr, KindDecl, KindPkg, false},
+			{KindPkg, KindExpr, KindFile, false},
+			{KindPkg + 1, KindNode, KindPkg, false},
+			{KindNode - 1, KindNode, KindPkg, false},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - exp %v from %v.Between(%v, %v)`,
+				idx, test.exp, test.trg, test.a, test.b)
+			if exp, got := test.exp, test.trg.Between(test.a, test.b); exp != got {
+				t.Fatalf(`exp Kind Between() to return %v; got %v`, exp, got)
 			}
 		}
 	})
@@ -223,3 +1587,172 @@ func TestRecoverFn(t *testing.T) {
 		}
 	})
 }
+
+func BenchmarkSource(b *testing.B) {
+	type bench struct {
+		kind Kind
+		src  string
+	}
+	benches := []bench{
+		{KindExpr, `foo(1, "two", bar.baz)`},
+		{KindDecl, `foo := 42; bar(foo)`},
+		{KindFile, `func foo() { bar(42) }`},
+		{KindPkg, `package foo; func foo() { bar(42) }`},
+		{KindNode, `{`},
+	}
+	for _, bench := range benches {
+		name := bench.kind.String()
+		_, attempts := SourceTrace(bench.src)
+		if last := attempts[len(attempts)-1]; last.Err != nil {
+			name = `Fail`
+		} else if last.Kind != bench.kind {
+			b.Fatalf(`exp src %q to parse at %v; got %v`, bench.src, bench.kind, last.Kind)
+		}
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Source(bench.src)
+			}
+		})
+	}
+}
+
+func BenchmarkExpand(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		expand(`foo := 42`, KindExpr, KindPkg)
+	}
+}
+
+// BenchmarkClassify compares climbing from KindExpr against starting the parse
+// at the kind src is known to be, which is the cost a classification of src
+// before parsing could save.
+func BenchmarkClassify(b *testing.B) {
+	// Each sub-benchmark is named by the kind its src parses at.
+	type bench struct {
+		kind Kind
+		src  string
+	}
+	benches := []bench{
+		{KindExpr, `foo(1, "two", bar.baz)`},
+		{KindDecl, "x := foo(1)\nif x > 0 {\n\treturn bar(x)\n}"},
+		{KindFile, "type T struct{}\n\nfunc (T) M() { bar(42) }"},
+		{KindPkg, "package foo\n\nfunc foo() { bar(42) }"},
+	}
+	for _, bench := range benches {
+		_, attempts := SourceTrace(bench.src)
+		if last := attempts[len(attempts)-1]; last.Err != nil || last.Kind != bench.kind {
+			b.Fatalf(`exp src %q to parse at %v; got %v (%v)`,
+				bench.src, bench.kind, last.Kind, last.Err)
+		}
+
+		b.Run(bench.kind.String()+`/Climb`, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Source(bench.src)
+			}
+		})
+		b.Run(bench.kind.String()+`/Direct`, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var (
+					node ast.Node
+					err  error
+				)
+				fset := token.NewFileSet()
+				if bench.kind == KindExpr {
+					node, err = parser.ParseExprFrom(fset, `string.go`, bench.src, 0)
+				} else {
+					src := expand(bench.src, bench.kind, KindPkg)
+					node, err = parser.ParseFile(fset, `string.go`, src, 0)
+				}
+				if err != nil {
+					b.Fatalf(`exp nil err parsing %q at %v; got %v`, bench.src, bench.kind, err)
+				}
+				reduce(node)
+			}
+		})
+	}
+}
+
+func TestSourceMultiline(t *testing.T) {
+	type test struct {
+		src  string
+		line string
+		fmt  string
+	}
+	tests := []test{
+		{"\"foo\" +\n\"bar\"", `"foo" + "bar"`, "\"foo\" +\n\t\"bar\""},
+		{"\"foo\" +\n\t\"bar\" +\n\t\"baz\"", `"foo" + "bar" + "baz"`,
+			"\"foo\" +\n\t\"bar\" +\n\t\"baz\""},
+		{"x := \"foo\" +\n\t\"bar\"", `x := "foo" + "bar"`, "x := \"foo\" +\n\t\"bar\""},
+		{"f(\"a\" +\n\t\"b\")", `f("a" + "b")`, "f(\"a\" +\n\t\"b\")"},
+		{"`a\n\tb` +\n\t`c`", "`a\n\tb` + `c`", "`a\n\tb` +\n\t`c`"},
+		{"a &&\n\tb ||\n\tc", `a && b || c`, "a &&\n\tb ||\n\tc"},
+		{"x = \"a\" +\n\t\"b\"\ny = 1", "x = \"a\" + \"b\"; y = 1", "{\n\tx = \"a\" +\n\t\t\"b\"\n\ty = 1\n}"},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.fmt)
+
+		for from := KindExpr; from <= KindPkg; from++ {
+			if !strings.Contains(Expand(test.src, KindExpr, from), test.src) {
+				t.Fatalf(`exp expanding to %v to keep the lines of src`, from)
+			}
+		}
+		for _, opts := range []Options{{}, {ExprViaFile: true}} {
+			node, err := opts.parse(token.NewFileSet(), test.src, nil)
+			if err != nil {
+				t.Fatalf(`exp nil err from parse with %+v; got %v`, opts, err)
+			}
+			if exp := Source(test.line); !Equal(exp, node) {
+				t.Fatalf("exp multi-line src to equal %q:\n%v", test.line, Diff(exp, node))
+			}
+		}
+
+		got, err := SourceFormatted(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceFormatted; got %v`, err)
+		}
+		if exp := test.fmt; exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+}
+
+func TestSourceFormatted(t *testing.T) {
+	type test struct {
+		src string
+		exp string
+	}
+	tests := []test{
+		{``, `_`},
+		{"// hi", `_`},
+		{`x`, `x`},
+		{`a+b*c`, `a + b*c`},
+		{`_ = someCall( 1,2 )`, `_ = someCall(1, 2)`},
+		{`if x {y()}`, "if x {\n\ty()\n}"},
+		{"func f() {\n\tx := 1\n\n\t_ = x\n}", "func f() {\n\tx := 1\n\n\t_ = x\n}"},
+		{`type T struct{A int; Bcd string}`, "type T struct {\n\tA   int\n\tBcd string\n}"},
+		{`package main; import "fmt"`, "package main\n\nimport \"fmt\"\n"},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.exp)
+
+		got, err := SourceFormatted(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceFormatted; got %v`, err)
+		}
+		if exp := test.exp; exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{`{`, `x :=`, `func {`} {
+			if got, err := SourceFormatted(src); err == nil {
+				t.Fatalf(`exp non-nil err from SourceFormatted(%q); got %q`, src, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,58 @@
+// Package astfromtest provides helpers for testing code which produces Go ASTs,
+// such as code generators, against golden files.
+package astfromtest
+
+import (
+	"flag"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/cstockton/astgen/astfrom"
+)
+
+// Golden compares the dump of node by astfrom.Dump against the contents of the
+// golden file at path, failing t with both dumps when they differ or the file
+// can't be read. When the test binary defines an update flag which is set, such
+// as with flag.Bool("update", false, "") in the test package and -update given
+// to go test, the golden file is written instead, creating any missing parent
+// directories. The flag is looked up on each call, as registering it here would
+// collide with test packages defining their own.
+//
+// The dump omits positions, so golden files are unaffected by changes to the
+// whitespace or formatting of the source a node was parsed from.
+func Golden(t testing.TB, node ast.Node, path string) {
+	t.Helper()
+
+	got := astfrom.Dump(node, 0)
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf(`unable to create golden file directory: %v`, err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf(`unable to update golden file: %v`, err)
+		}
+		return
+	}
+
+	exp, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf(`unable to read golden file, run go test with -update to create it: %v`, err)
+	}
+	if string(exp) != got {
+		t.Errorf("golden file %v differs, run go test with -update to accept the changes:"+
+			"\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", path, string(exp), got)
+	}
+}
+
+// updating reports if the update flag is defined and set to true.
+func updating() bool {
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	v, _ := strconv.ParseBool(f.Value.String())
+	return v
+}
@@ -0,0 +1,73 @@
+package astfromtest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cstockton/astgen/astfrom"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// recorder is a testing.TB which records failures rather than failing the
+// test, so Golden's own failures may be asserted.
+type recorder struct {
+	testing.TB
+	failed bool
+	msg    string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failed, r.msg = true, fmt.Sprintf(format, args...)
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+func TestGolden(t *testing.T) {
+	defer func(v bool) { *update = v }(*update)
+
+	path := filepath.Join(t.TempDir(), `testdata`, `x.golden`)
+	node := astfrom.Source(`x := f(1)`)
+
+	*update = false
+	rec := &recorder{TB: t}
+	Golden(rec, node, path)
+	if !rec.failed || !strings.Contains(rec.msg, `-update`) {
+		t.Fatalf(`exp missing golden file to fail with a hint; got %q`, rec.msg)
+	}
+
+	*update = true
+	rec = &recorder{TB: t}
+	Golden(rec, node, path)
+	if rec.failed {
+		t.Fatalf(`exp nil err updating golden file; got %v`, rec.msg)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf(`exp golden file to be written; got %v`, err)
+	}
+	if exp, got := astfrom.Dump(node, 0), string(b); exp != got {
+		t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+	}
+
+	*update = false
+	rec = &recorder{TB: t}
+	Golden(rec, astfrom.Source("x  :=  f(\n\t1,\n)"), path)
+	if rec.failed {
+		t.Fatalf(`exp equal golden file to pass; got %v`, rec.msg)
+	}
+
+	rec = &recorder{TB: t}
+	Golden(rec, astfrom.Source(`x := f(2)`), path)
+	if !rec.failed || !strings.Contains(rec.msg, `"2"`) {
+		t.Fatalf(`exp differing golden file to fail with both dumps; got %q`, rec.msg)
+	}
+}
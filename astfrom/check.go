@@ -0,0 +1,143 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// Check parses src like Source and type checks the complete file it was
+// expanded to, including the scaffolding. The returned node is the reduced
+// node, which is a subtree of the checked file, so it and all of its children
+// may be used directly as keys into the returned *types.Info. Expressions are
+// checked as the value of a blank assignment, or as a statement when they're a
+// call or receive which fails to check as one, so a call with no result or
+// several results such as `fmt.Println("hi")` may be checked.
+//
+// Soft errors such as unused variables or imports, which are common within
+// snippets, are ignored. The first hard error is returned along with the node
+// and the partially populated info, as a types.Error positioned within src. An
+// elided composite literal such as `{1, 2}` is returned with a nil info and
+// ErrElidedLit.
+func Check(src string) (ast.Node, *types.Info, error) {
+	return CheckWith(src, Options{})
+}
+
+// CheckWith behaves like Check using the given Options. Packages referenced
+// by src may be brought into scope using Options.Imports.
+func CheckWith(src string, opts Options) (ast.Node, *types.Info, error) {
+	var (
+		last Attempt
+		fset = token.NewFileSet()
+	)
+
+	// Expressions are always expanded below, so parse them directly.
+	opts.ExprViaFile = false
+	node, err := opts.trace(fset, src, func(a Attempt) {
+		last = a
+	})
+	if err != nil {
+		return opts.failed(src, err), nil, err
+	}
+
+	// The scaffolding of an elided composite literal declares no type for it,
+	// so there is nothing to check it against.
+	if lit, ok := node.(*ast.CompositeLit); ok && lit.Type == nil {
+		return node, nil, ErrElidedLit
+	}
+
+	m, _ := opts.sourceMap(src)
+	file, ok := node.(*ast.File)
+	if ok {
+		if err = opts.verifyReduce(file); err != nil {
+			return opts.failed(src, err), nil, err
+		}
+		info, err := check(fset, file, m, last.offset)
+		if rerr := recoverFn(func() error {
+			node = opts.reduce(file)
+			return nil
+		}); rerr != nil {
+			return opts.failed(src, rerr), info, rerr
+		}
+		return node, info, err
+	}
+
+	// Expressions are checked as the value of a blank assignment. Calls and
+	// receives failing to check as one, which may have no value or several
+	// values, are checked again as an expression statement.
+	prefixes := []string{`_ = `}
+	if x, _ := node.(ast.Expr); stmtExpr(x) {
+		prefixes = append(prefixes, ``)
+	}
+	var info *types.Info
+	for _, prefix := range prefixes {
+		offset := strings.Index(opts.expand(prefix+scaffoldMark, KindDecl, KindPkg), scaffoldMark)
+		cur := opts.expand(prefix+last.Src, KindDecl, KindPkg)
+		if file, err = parser.ParseFile(fset, `string.go`, cur, opts.Mode); err != nil {
+			err = m.mapErr(err, Attempt{offset: offset})
+			return opts.failed(src, err), nil, err
+		}
+		if info, err = check(fset, file, m, offset); err == nil {
+			break
+		}
+	}
+	return checkedExpr(opts.reduce(file)), info, err
+}
+
+// check type checks file, returning the info and the first hard error with its
+// position mapped to the source at offset within file, see mapTypeErr.
+func check(fset *token.FileSet, file *ast.File, m *sourceMap, offset int) (*types.Info, error) {
+	var hardErr error
+	conf := types.Config{
+		Importer: importer.Default(),
+		Error: func(err error) {
+			if terr, ok := err.(types.Error); ok && terr.Soft {
+				return
+			}
+			if hardErr == nil {
+				hardErr = mapTypeErr(err, fset, m, offset)
+			}
+		},
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	conf.Check(file.Name.Name, fset, []*ast.File{file}, info)
+	return info, hardErr
+}
+
+// checkedExpr returns the expression of stmt, the statement an expression was
+// checked as by CheckWith.
+func checkedExpr(stmt ast.Node) ast.Node {
+	switch T := stmt.(type) {
+	case *ast.ExprStmt:
+		return T.X
+	case *ast.AssignStmt:
+		return T.Rhs[0]
+	}
+	return stmt
+}
+
+// mapTypeErr returns err with the position of a types.Error mapped from the
+// checked file, where src begins at offset, to src itself. The position is
+// resolved by a file set holding only src, so the error reads like "1:5: msg".
+func mapTypeErr(err error, fset *token.FileSet, m *sourceMap, offset int) error {
+	terr, ok := err.(types.Error)
+	if !ok || !terr.Pos.IsValid() {
+		return err
+	}
+	pos := m.position(fset.Position(terr.Pos).Offset - offset)
+	srcFset := token.NewFileSet()
+	file := srcFset.AddFile(``, -1, len(m.src))
+	file.SetLinesForContent([]byte(m.src))
+	terr.Fset, terr.Pos = srcFset, file.Pos(pos.Offset)
+	return terr
+}
@@ -0,0 +1,161 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	type test struct {
+		src string
+		exp string
+	}
+	tests := []test{
+		{`1 + 2`, `int`},
+		{`"a" + "b"`, `string`},
+		{`len("abc")`, `int`},
+		{`[]int{1}`, `[]int`},
+		{`func() bool { return true }()`, `bool`},
+		{`struct{ X float64 }{1}.X`, `float64`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		node, info, err := Check(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from Check; got %v`, err)
+		}
+		expr, ok := node.(ast.Expr)
+		if !ok {
+			t.Fatalf(`exp ast.Expr from Check; got %T`, node)
+		}
+		if exp, got := test.exp, info.TypeOf(expr).String(); exp != got {
+			t.Fatalf(`exp type %v; got %v`, exp, got)
+		}
+	}
+
+	t.Run(`Stmt`, func(t *testing.T) {
+		node, info, err := Check(`x := 1.5`)
+		if err != nil {
+			t.Fatalf(`exp nil err from Check; got %v`, err)
+		}
+		assign, ok := node.(*ast.AssignStmt)
+		if !ok {
+			t.Fatalf(`exp *ast.AssignStmt from Check; got %T`, node)
+		}
+		obj := info.Defs[assign.Lhs[0].(*ast.Ident)]
+		if obj == nil {
+			t.Fatal(`exp object defined for x`)
+		}
+		if exp, got := `float64`, obj.Type().String(); exp != got {
+			t.Fatalf(`exp type %v; got %v`, exp, got)
+		}
+	})
+	t.Run(`Package`, func(t *testing.T) {
+		node, info, err := Check(`package p; import "strings"; var x = strings.ToUpper("a")`)
+		if err != nil {
+			t.Fatalf(`exp nil err from Check; got %v`, err)
+		}
+		if _, ok := node.(*ast.File); !ok {
+			t.Fatalf(`exp *ast.File from Check; got %T`, node)
+		}
+		var found bool
+		for id, obj := range info.Defs {
+			if id.Name != `x` {
+				continue
+			}
+			found = true
+			if exp, got := `string`, obj.Type().String(); exp != got {
+				t.Fatalf(`exp type %v; got %v`, exp, got)
+			}
+		}
+		if !found {
+			t.Fatal(`exp object defined for x`)
+		}
+	})
+	t.Run(`Imports`, func(t *testing.T) {
+		src := `fmt.Sprint(1)`
+		if _, _, err := Check(src); err == nil {
+			t.Fatal(`exp non-nil err from Check without imports`)
+		}
+
+		node, info, err := CheckWith(src, Options{Imports: []string{`fmt`}})
+		if err != nil {
+			t.Fatalf(`exp nil err from CheckWith; got %v`, err)
+		}
+		if exp, got := `string`, info.TypeOf(node.(ast.Expr)).String(); exp != got {
+			t.Fatalf(`exp type %v; got %v`, exp, got)
+		}
+	})
+	t.Run(`Calls`, func(t *testing.T) {
+		type test struct {
+			src string
+			exp string
+		}
+		tests := []test{
+			{`fmt.Println("hi")`, `(n int, err error)`},
+			{`println("x")`, `()`},
+			{`strings.Cut("a", "b")`, `(before string, after string, found bool)`},
+			{`(fmt.Sprint(1))`, `string`},
+			{`<-make(chan int)`, `int`},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+			node, info, err := CheckWith(test.src, Options{Imports: []string{`fmt`, `strings`}})
+			if err != nil {
+				t.Fatalf(`exp nil err from CheckWith; got %v`, err)
+			}
+			expr, ok := node.(ast.Expr)
+			if !ok {
+				t.Fatalf(`exp ast.Expr from CheckWith; got %T`, node)
+			}
+			if exp, got := test.exp, info.TypeOf(expr).String(); exp != got {
+				t.Fatalf(`exp type %v; got %v`, exp, got)
+			}
+		}
+	})
+	t.Run(`Positions`, func(t *testing.T) {
+		type test struct {
+			src string
+			exp string
+		}
+		tests := []test{
+			{`"a" + 1`, `1:1: invalid operation`},
+			{`len(undefinedIdent)`, `1:5: undefined: undefinedIdent`},
+			{"\n  x := 1\n  y := x + \"a\"", `3:8: invalid operation`},
+			{`package p; var x int = "s"`, `1:24: cannot use "s"`},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+			_, _, err := Check(test.src)
+			if _, ok := err.(types.Error); !ok {
+				t.Fatalf(`exp types.Error from Check; got %T`, err)
+			}
+			if got := err.Error(); !strings.HasPrefix(got, test.exp) {
+				t.Fatalf(`exp err with prefix %q; got %q`, test.exp, got)
+			}
+		}
+	})
+	t.Run(`ElidedLit`, func(t *testing.T) {
+		for _, src := range []string{`{1, 2}`, `{"a": 1}`, `{{1}, {2}}`} {
+			node, info, err := Check(src)
+			if exp, got := ErrElidedLit, err; exp != got {
+				t.Fatalf(`exp err %v from Check(%q); got %v`, exp, src, got)
+			}
+			if _, ok := node.(*ast.CompositeLit); !ok || info != nil {
+				t.Fatalf(`exp *ast.CompositeLit and nil info from Check(%q); got %T %v`, src, node, info)
+			}
+		}
+	})
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{`undefinedIdent + 1`, `"a" + 1`, `{`} {
+			if _, _, err := Check(src); err == nil {
+				t.Fatalf(`exp non-nil err from Check(%q)`, src)
+			}
+		}
+	})
+}
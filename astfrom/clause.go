@@ -0,0 +1,105 @@
+package astfrom
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// SourceCase parses src as a single clause of an expression or type switch,
+// such as `case 1, 2: doThing()` or `default:`, by scaffolding it within a
+// switch statement of the sentinel func. The List of a default clause is nil.
+// An error is returned when src is not exactly one case clause.
+func SourceCase(src string) (*ast.CaseClause, error) {
+	stmt, err := sourceClause(src, `switch`)
+	if err != nil {
+		return nil, err
+	}
+	clause, ok := stmt.(*ast.CaseClause)
+	if !ok {
+		return nil, fmt.Errorf("expected case clause, found %T", stmt)
+	}
+	return clause, nil
+}
+
+// SourceComm parses src as a single clause of a select statement, such as
+// `case v := <-ch: use(v)` or `case ch <- v:`, by scaffolding it within a
+// select statement of the sentinel func. The Comm of a default clause is nil.
+// An error is returned when src is not exactly one comm clause.
+func SourceComm(src string) (*ast.CommClause, error) {
+	stmt, err := sourceClause(src, `select`)
+	if err != nil {
+		return nil, err
+	}
+	clause, ok := stmt.(*ast.CommClause)
+	if !ok {
+		return nil, fmt.Errorf("expected comm clause, found %T", stmt)
+	}
+	if clause.Comm != nil && !commStmt(clause.Comm) {
+		return nil, fmt.Errorf("expected send or receive in comm clause, found %T", clause.Comm)
+	}
+	return clause, nil
+}
+
+// commStmt reports whether stmt is a send or receive statement, which go/parser
+// doesn't enforce for the Comm of a clause.
+func commStmt(stmt ast.Stmt) bool {
+	var x ast.Expr
+	switch T := stmt.(type) {
+	case *ast.SendStmt:
+		return true
+	case *ast.ExprStmt:
+		x = T.X
+	case *ast.AssignStmt:
+		if len(T.Rhs) != 1 {
+			return false
+		}
+		x = T.Rhs[0]
+	}
+	recv, ok := ast.Unparen(x).(*ast.UnaryExpr)
+	return ok && recv.Op == token.ARROW
+}
+
+// sourceClause parses src as the only clause within the body of a statement
+// beginning with keyword, returning the clause.
+func sourceClause(src, keyword string) (ast.Stmt, error) {
+	if src = trimLines(src); len(src) == 0 {
+		return nil, fmt.Errorf("expected clause, found empty source")
+	}
+
+	prefix := "package " + pkgSentinel + "\n\nfunc " + fnSentinel + " {\n\t" + keyword + " {\n"
+	var clause ast.Stmt
+	err := recoverFn(func() error {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, `string.go`, prefix+src+"\n\t}\n}\n", 0)
+		if err != nil {
+			return err
+		}
+
+		fn, ok := f.Decls[0].(*ast.FuncDecl)
+		if !ok || len(f.Decls) != 1 || fn.Body == nil || len(fn.Body.List) != 1 {
+			return fmt.Errorf("expected %v clause", keyword)
+		}
+
+		var body *ast.BlockStmt
+		switch T := fn.Body.List[0].(type) {
+		case *ast.SwitchStmt:
+			body = T.Body
+		case *ast.SelectStmt:
+			body = T.Body
+		}
+		if body == nil || fset.Position(body.Rbrace).Offset != len(prefix)+len(src)+2 {
+			return fmt.Errorf("expected %v clause", keyword)
+		}
+		if n := len(body.List); n != 1 {
+			return fmt.Errorf("expected a single %v clause, found %v", keyword, n)
+		}
+		clause = body.List[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clause, nil
+}
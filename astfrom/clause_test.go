@@ -0,0 +1,101 @@
+package astfrom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSourceCase(t *testing.T) {
+	type test struct {
+		src  string
+		list []string
+		body []string
+	}
+	tests := []test{
+		{`case 1, 2: doThing()`, []string{`1`, `2`}, []string{`doThing()`}},
+		{`case x > 0:`, []string{`x > 0`}, nil},
+		{`default:`, nil, nil},
+		{`default: return`, nil, []string{`return`}},
+		{`case int, *T:`, []string{`int`, `*T`}, nil},
+		{"case \"a\":\n\tx := 1\n\tf(x)\n\tfallthrough", []string{`"a"`}, []string{`x := 1`, `f(x)`, `fallthrough`}},
+		{`case f(): g() // comment`, []string{`f()`}, []string{`g()`}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.list)
+
+		clause, err := SourceCase(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceCase; got %v`, err)
+		}
+		if (test.list == nil) != (clause.List == nil) {
+			t.Fatalf(`exp nil List %v; got %v`, test.list == nil, clause.List == nil)
+		}
+		var list, body []string
+		for _, expr := range clause.List {
+			list = append(list, sprint(t, expr))
+		}
+		for _, stmt := range clause.Body {
+			body = append(body, sprint(t, stmt))
+		}
+		if exp, got := strings.Join(test.list, `, `), strings.Join(list, `, `); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+		if exp, got := strings.Join(test.body, "\n"), strings.Join(body, "\n"); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{
+			``, `x`, `case 1:; case 2:`, "case 1:\ncase 2:", `case:`,
+			`case 1: } }; func f() { switch { case 2:`, `case v := <-ch:`, `default: }`,
+		} {
+			if clause, err := SourceCase(src); err == nil {
+				t.Fatalf(`exp non-nil err from SourceCase(%q); got %v`, src, clause.List)
+			}
+		}
+	})
+}
+
+func TestSourceComm(t *testing.T) {
+	type test struct {
+		src  string
+		comm string
+		body []string
+	}
+	tests := []test{
+		{`case v := <-ch: use(v)`, `v := <-ch`, []string{`use(v)`}},
+		{`case ch <- v:`, `ch <- v`, nil},
+		{`case <-done: return`, `<-done`, []string{`return`}},
+		{`case v, ok = <-ch:`, `v, ok = <-ch`, nil},
+		{"default:\n\tf()\n\tg()", ``, []string{`f()`, `g()`}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.comm)
+
+		clause, err := SourceComm(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceComm; got %v`, err)
+		}
+		if exp, got := test.comm, sprint(t, clause.Comm); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+		var body []string
+		for _, stmt := range clause.Body {
+			body = append(body, sprint(t, stmt))
+		}
+		if exp, got := strings.Join(test.body, "\n"), strings.Join(body, "\n"); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{
+			``, `<-ch`, `case 1, 2:`, `case x > 0:`, "case <-a:\ncase <-b:", `case <-ch: } }`, `case f():`, `case v := f():`,
+		} {
+			if clause, err := SourceComm(src); err == nil {
+				t.Fatalf(`exp non-nil err from SourceComm(%q); got %v`, src, sprint(t, clause.Comm))
+			}
+		}
+	})
+}
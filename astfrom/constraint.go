@@ -0,0 +1,52 @@
+package astfrom
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// SourceConstraint parses src as a single element of a type constraint, such
+// as the union `~int | ~string`, by embedding it within the interface type of
+// a sentinel type declaration. A union is returned as an *ast.BinaryExpr with
+// the Op token.OR, while an approximation such as `~int` is an *ast.UnaryExpr
+// with the Op token.TILDE. Plain types such as `comparable` or `fmt.Stringer`
+// are returned as is. An error is returned when src is not exactly one
+// element, such as a method or several elements separated by semicolons.
+func SourceConstraint(src string) (ast.Expr, error) {
+	if src = trimLines(src); len(src) == 0 {
+		return nil, fmt.Errorf("expected constraint, found empty source")
+	}
+
+	prefix := "package " + pkgSentinel + "\n\ntype " + litSentinel + " interface {\n"
+	var expr ast.Expr
+	err := recoverFn(func() error {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, `string.go`, prefix+src+"\n}\n", 0)
+		if err != nil {
+			return err
+		}
+
+		gen, ok := f.Decls[0].(*ast.GenDecl)
+		if !ok || len(f.Decls) != 1 || len(gen.Specs) != 1 {
+			return fmt.Errorf("expected constraint")
+		}
+		iface, ok := gen.Specs[0].(*ast.TypeSpec).Type.(*ast.InterfaceType)
+		if !ok || fset.Position(iface.Methods.Closing).Offset != len(prefix)+len(src)+1 {
+			return fmt.Errorf("expected constraint")
+		}
+		if n := len(iface.Methods.List); n != 1 {
+			return fmt.Errorf("expected a single constraint element, found %v", n)
+		}
+		if elem := iface.Methods.List[0]; len(elem.Names) > 0 {
+			return fmt.Errorf("expected constraint element, found method %v", elem.Names[0].Name)
+		}
+		expr = iface.Methods.List[0].Type
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
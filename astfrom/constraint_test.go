@@ -0,0 +1,64 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestSourceConstraint(t *testing.T) {
+	type test struct {
+		src string
+		exp ast.Expr
+		fmt string
+	}
+	tests := []test{
+		{`int`, &ast.Ident{}, `int`},
+		{`comparable`, &ast.Ident{}, `comparable`},
+		{`fmt.Stringer`, &ast.SelectorExpr{}, `fmt.Stringer`},
+		{`~int`, &ast.UnaryExpr{Op: token.TILDE}, `~int`},
+		{`int | string`, &ast.BinaryExpr{Op: token.OR}, `int | string`},
+		{`~int | ~string`, &ast.BinaryExpr{Op: token.OR}, `~int | ~string`},
+		{`~int8 | ~int16 | int32 | ~[]byte`, &ast.BinaryExpr{Op: token.OR}, `~int8 | ~int16 | int32 | ~[]byte`},
+		{"~int |\n\t~uint // integers", &ast.BinaryExpr{Op: token.OR}, `~int | ~uint`},
+		{`~[]E`, &ast.UnaryExpr{Op: token.TILDE}, `~[]E`},
+		{`Number[T]`, &ast.IndexExpr{}, `Number[T]`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T`, idx, test.src, test.exp)
+
+		expr, err := SourceConstraint(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceConstraint; got %v`, err)
+		}
+		switch T := test.exp.(type) {
+		case *ast.UnaryExpr:
+			if got, ok := expr.(*ast.UnaryExpr); !ok || got.Op != T.Op {
+				t.Fatalf(`exp *ast.UnaryExpr with Op %v; got %T`, T.Op, expr)
+			}
+		case *ast.BinaryExpr:
+			if got, ok := expr.(*ast.BinaryExpr); !ok || got.Op != T.Op {
+				t.Fatalf(`exp *ast.BinaryExpr with Op %v; got %T`, T.Op, expr)
+			}
+		default:
+			if exp, got := reflect.TypeOf(test.exp), reflect.TypeOf(expr); exp != got {
+				t.Fatalf(`exp %v; got %v`, exp, got)
+			}
+		}
+		if exp, got := test.fmt, sprint(t, expr); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{
+			``, `M()`, `int; string`, "int\nstring", `~`, `int |`,
+			"int\n}\n\nfunc f() {\n\tg()", `x := 1`,
+		} {
+			if expr, err := SourceConstraint(src); err == nil {
+				t.Fatalf(`exp non-nil err from SourceConstraint(%q); got %v`, src, sprint(t, expr))
+			}
+		}
+	})
+}
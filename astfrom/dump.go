@@ -0,0 +1,148 @@
+package astfrom
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strings"
+)
+
+// Dump returns an indented tree describing node. The root is at depth 1 and
+// each child node is one deeper, with nodes deeper than maxDepth elided as
+// "...", so a maxDepth of 1 dumps the fields of the root with its child nodes
+// elided. A maxDepth less than 1 dumps the entire tree. Positions, object
+// resolution fields and nil or empty fields are omitted to keep the output
+// compact.
+//
+// A node which contains itself, as may happen in hand built trees, is only
+// dumped once. The node is marked with an id such as "#1" and each repeated
+// occurrence within it is rendered as a back-reference "<cycle to #1>".
+func Dump(node ast.Node, maxDepth int) string {
+	d := &dumper{
+		max:  maxDepth,
+		path: make(map[ptrKey]bool),
+		ids:  make(map[ptrKey]int),
+	}
+
+	// The first pass finds the nodes which are the target of a cycle, so
+	// their ids are known when they're printed by a second pass.
+	d.value(reflect.ValueOf(node), 1, ``)
+	if len(d.ids) == 0 {
+		return d.buf.String()
+	}
+	d.buf.Reset()
+	d.printed = true
+	d.value(reflect.ValueOf(node), 1, ``)
+	return d.buf.String()
+}
+
+type dumper struct {
+	buf     strings.Builder
+	max     int
+	path    map[ptrKey]bool
+	ids     map[ptrKey]int
+	printed bool
+}
+
+// ptrKey identifies a pointer to a node during cycle detection.
+type ptrKey struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+func (d *dumper) printf(format string, a ...interface{}) {
+	fmt.Fprintf(&d.buf, format, a...)
+}
+
+// value writes v to the current line followed by any children on the lines
+// below it. Depth is incremented for each struct (node) that is descended.
+func (d *dumper) value(v reflect.Value, depth int, indent string) {
+	if !v.IsValid() {
+		d.printf("nil\n")
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			d.printf("nil\n")
+			return
+		}
+		if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+			d.pointer(v, depth, indent)
+			return
+		}
+		d.value(v.Elem(), depth, indent)
+	case reflect.Struct:
+		d.node(v.Type(), v, depth, indent, 0)
+	case reflect.Slice:
+		d.printf("%v (len %d)\n", v.Type(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			d.printf("%v  %d: ", indent, i)
+			d.value(v.Index(i), depth, indent+`  `)
+		}
+	case reflect.String:
+		d.printf("%q\n", v.String())
+	default:
+		if v.CanInterface() {
+			d.printf("%v\n", v.Interface())
+		} else {
+			d.printf("%v\n", v)
+		}
+	}
+}
+
+// pointer writes the node pointed to by v, or a back-reference when v is an
+// ancestor of itself.
+func (d *dumper) pointer(v reflect.Value, depth int, indent string) {
+	key := ptrKey{typ: v.Type(), ptr: v.Pointer()}
+	if d.path[key] {
+		if _, ok := d.ids[key]; !ok && !d.printed {
+			d.ids[key] = len(d.ids) + 1
+		}
+		d.printf("<cycle to #%d>\n", d.ids[key])
+		return
+	}
+
+	d.path[key] = true
+	defer delete(d.path, key)
+	d.node(v.Type(), v.Elem(), depth, indent, d.ids[key])
+}
+
+func (d *dumper) node(typ reflect.Type, v reflect.Value, depth int, indent string, id int) {
+	if d.max > 0 && depth > d.max {
+		d.printf("%v ...\n", typ)
+		return
+	}
+	if id > 0 {
+		d.printf("%v #%d\n", typ, id)
+	} else {
+		d.printf("%v\n", typ)
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		f, fv := v.Type().Field(i), v.Field(i)
+		if presenceField(f) {
+			if fv.Int() != 0 {
+				d.printf("%v  %v: true\n", indent, f.Name)
+			}
+			continue
+		}
+		if ignoredField(f) || omitted(fv) {
+			continue
+		}
+		d.printf("%v  %v: ", indent, f.Name)
+		d.value(fv, depth+1, indent+`  `)
+	}
+}
+
+// omitted reports whether v is a nil or empty value omitted from dumps.
+func omitted(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return false
+}
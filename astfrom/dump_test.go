@@ -0,0 +1,99 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	type test struct {
+		src   string
+		depth int
+		exp   string
+	}
+	tests := []test{
+		{`foo`, 0, "*ast.Ident\n  Name: \"foo\"\n"},
+		{`foo`, 1, "*ast.Ident\n  Name: \"foo\"\n"},
+		{`x := 1`, 0, strings.Join([]string{
+			`*ast.AssignStmt`,
+			`  Lhs: []ast.Expr (len 1)`,
+			`    0: *ast.Ident`,
+			`      Name: "x"`,
+			`  Tok: :=`,
+			`  Rhs: []ast.Expr (len 1)`,
+			`    0: *ast.BasicLit`,
+			`      Kind: INT`,
+			`      Value: "1"`,
+		}, "\n") + "\n"},
+		{`x := 1`, 1, strings.Join([]string{
+			`*ast.AssignStmt`,
+			`  Lhs: []ast.Expr (len 1)`,
+			`    0: *ast.Ident ...`,
+			`  Tok: :=`,
+			`  Rhs: []ast.Expr (len 1)`,
+			`    0: *ast.BasicLit ...`,
+		}, "\n") + "\n"},
+		{`if x { y() }`, 2, strings.Join([]string{
+			`*ast.IfStmt`,
+			`  Cond: *ast.Ident`,
+			`    Name: "x"`,
+			`  Body: *ast.BlockStmt`,
+			`    List: []ast.Stmt (len 1)`,
+			`      0: *ast.ExprStmt ...`,
+		}, "\n") + "\n"},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q with depth %v`, idx, test.src, test.depth)
+		if exp, got := test.exp, Dump(Source(test.src), test.depth); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Ellipsis`, func(t *testing.T) {
+		if strings.Contains(Dump(Source(`f(a)`), 0), `Ellipsis`) {
+			t.Fatal(`exp Ellipsis to be omitted for non-variadic calls`)
+		}
+		if !strings.Contains(Dump(Source(`f(a...)`), 0), "  Ellipsis: true\n") {
+			t.Fatal(`exp Ellipsis to be dumped for variadic calls`)
+		}
+	})
+	t.Run(`Cycle`, func(t *testing.T) {
+		lit := &ast.FuncLit{Type: &ast.FuncType{}, Body: &ast.BlockStmt{}}
+		lit.Body.List = []ast.Stmt{&ast.ExprStmt{X: lit}}
+		exp := strings.Join([]string{
+			`*ast.FuncLit #1`,
+			`  Type: *ast.FuncType`,
+			`  Body: *ast.BlockStmt`,
+			`    List: []ast.Stmt (len 1)`,
+			`      0: *ast.ExprStmt`,
+			`        X: <cycle to #1>`,
+		}, "\n") + "\n"
+		if got := Dump(lit, 0); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+		if got := Dump(lit, 2); strings.Contains(got, `cycle`) {
+			t.Fatalf(`exp cycle beyond max depth to be elided; got %v`, got)
+		}
+	})
+	t.Run(`Shared`, func(t *testing.T) {
+		id := ast.NewIdent(`x`)
+		exp := strings.Join([]string{
+			`*ast.BinaryExpr`,
+			`  X: *ast.Ident`,
+			`    Name: "x"`,
+			`  Op: +`,
+			`  Y: *ast.Ident`,
+			`    Name: "x"`,
+		}, "\n") + "\n"
+		if got := Dump(&ast.BinaryExpr{X: id, Op: token.ADD, Y: id}, 0); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	})
+	t.Run(`Nil`, func(t *testing.T) {
+		if exp, got := "nil\n", Dump(nil, 0); exp != got {
+			t.Fatalf(`exp Dump to return %q; got %q`, exp, got)
+		}
+	})
+}
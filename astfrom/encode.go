@@ -0,0 +1,140 @@
+package astfrom
+
+import (
+	"encoding/gob"
+	"fmt"
+	"go/ast"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Encode writes node to w in the gob format, for decoding by Decode. The object
+// resolution fields populated by go/parser (Obj, Scope and Unresolved) form
+// cycles gob is unable to encode, so they are omitted from the encoded node
+// which is otherwise left unchanged. Positions are preserved as is, which are
+// only meaningful along with the token.FileSet node was parsed with.
+//
+// Nodes reachable through more than one path, such as the import specs listed
+// in both the Decls and Imports of a *ast.File, are encoded once per path and
+// no longer shared once decoded.
+func Encode(w io.Writer, node ast.Node) error {
+	if node == nil {
+		return fmt.Errorf("cannot encode nil node")
+	}
+	register()
+
+	v := detach(reflect.ValueOf(node))
+	if err := gob.NewEncoder(w).Encode(&encoded{Node: v.Interface().(ast.Node)}); err != nil {
+		return fmt.Errorf("unable to encode %T: %v", node, err)
+	}
+	return nil
+}
+
+// Decode reads a node written by Encode from r. The object resolution fields of
+// the returned node are always nil.
+func Decode(r io.Reader) (ast.Node, error) {
+	register()
+
+	var dec encoded
+	if err := gob.NewDecoder(r).Decode(&dec); err != nil {
+		return nil, fmt.Errorf("unable to decode node: %v", err)
+	}
+	if dec.Node == nil {
+		return nil, fmt.Errorf("unable to decode node: missing node")
+	}
+	return dec.Node, nil
+}
+
+// encoded is the value sent to gob, which requires interface values to be held
+// within a field.
+type encoded struct {
+	Node ast.Node
+}
+
+var registerOnce sync.Once
+
+// register registers each concrete node type with gob so they may be sent
+// within the interface fields of other nodes.
+func register() {
+	registerOnce.Do(func() {
+		for _, node := range []ast.Node{
+			// Exprs
+			&ast.BadExpr{}, &ast.Ident{}, &ast.Ellipsis{}, &ast.BasicLit{},
+			&ast.FuncLit{}, &ast.CompositeLit{}, &ast.ParenExpr{},
+			&ast.SelectorExpr{}, &ast.IndexExpr{}, &ast.IndexListExpr{},
+			&ast.SliceExpr{}, &ast.TypeAssertExpr{}, &ast.CallExpr{},
+			&ast.StarExpr{}, &ast.UnaryExpr{}, &ast.BinaryExpr{},
+			&ast.KeyValueExpr{}, &ast.ArrayType{}, &ast.StructType{},
+			&ast.FuncType{}, &ast.InterfaceType{}, &ast.MapType{},
+			&ast.ChanType{},
+
+			// Stmts
+			&ast.BadStmt{}, &ast.DeclStmt{}, &ast.EmptyStmt{},
+			&ast.LabeledStmt{}, &ast.ExprStmt{}, &ast.SendStmt{},
+			&ast.IncDecStmt{}, &ast.AssignStmt{}, &ast.GoStmt{},
+			&ast.DeferStmt{}, &ast.ReturnStmt{}, &ast.BranchStmt{},
+			&ast.BlockStmt{}, &ast.IfStmt{}, &ast.CaseClause{},
+			&ast.SwitchStmt{}, &ast.TypeSwitchStmt{}, &ast.CommClause{},
+			&ast.SelectStmt{}, &ast.ForStmt{}, &ast.RangeStmt{},
+
+			// Decls and specs
+			&ast.BadDecl{}, &ast.GenDecl{}, &ast.FuncDecl{},
+			&ast.ImportSpec{}, &ast.ValueSpec{}, &ast.TypeSpec{},
+
+			// Others
+			&ast.Comment{}, &ast.CommentGroup{}, &ast.Field{},
+			&ast.FieldList{}, &ast.File{}, &ast.Package{},
+		} {
+			gob.Register(node)
+		}
+	})
+}
+
+// detach returns a deep copy of v without the object resolution fields,
+// retaining positions unlike copyValue.
+func detach(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Type() == objType || v.Type() == scopeType {
+			return reflect.Zero(v.Type())
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(detach(v.Elem()))
+		return out
+	case reflect.Interface:
+		out := reflect.New(v.Type()).Elem()
+		if !v.IsNil() {
+			out.Set(detach(v.Elem()))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(detach(v.Index(i)))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() || v.Type().Elem() == objType {
+			return reflect.Zero(v.Type())
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), detach(iter.Value()))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Name != `Unresolved` {
+				out.Field(i).Set(detach(v.Field(i)))
+			}
+		}
+		return out
+	}
+	return v
+}
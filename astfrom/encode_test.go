@@ -0,0 +1,104 @@
+package astfrom
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	type test struct {
+		src string
+	}
+	tests := []test{
+		{`foo`},
+		{`a + b*c`},
+		{`x := 1`},
+		{`{1, 2, 3}`},
+		{`struct{ x int "tag" }`},
+		{`func(a ...int) (b int) { return len(a) }`},
+		{"for i := range xs {\n\tif i > 0 {\n\t\tbreak\n\t}\n}"},
+		{"switch x := y.(type) {\ncase int:\n\t_ = x\n}"},
+		{"func f[T any](v T) T {\n\treturn v\n}\n\nfunc g() {}"},
+		{"package p\n\nimport \"fmt\"\n\nvar x = fmt.Sprint(1)\n"},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q`, idx, test.src)
+
+		res, err := SourceResult(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceResult; got %v`, err)
+		}
+
+		var buf bytes.Buffer
+		if err := Encode(&buf, res.Node); err != nil {
+			t.Fatalf(`exp nil err from Encode; got %v`, err)
+		}
+		node, err := Decode(&buf)
+		if err != nil {
+			t.Fatalf(`exp nil err from Decode; got %v`, err)
+		}
+		if !Equal(res.Node, node) {
+			t.Fatalf("exp decoded node to be Equal:\n%v", Diff(res.Node, node))
+		}
+		if exp, got := res.Node.Pos(), node.Pos(); exp != got {
+			t.Fatalf(`exp decoded node to keep its Pos %v; got %v`, exp, got)
+		}
+
+		var exp, got strings.Builder
+		if err := format.Node(&exp, res.Fset, res.Node); err != nil {
+			t.Fatalf(`exp nil err formatting node; got %v`, err)
+		}
+		if err := format.Node(&got, res.Fset, node); err != nil {
+			t.Fatalf(`exp nil err formatting decoded node; got %v`, err)
+		}
+		if exp.String() != got.String() {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp.String(), got.String())
+		}
+	}
+
+	t.Run(`Objects`, func(t *testing.T) {
+		f, err := parser.ParseFile(token.NewFileSet(), ``, "package p\n\nvar x = y\n\nfunc f() { _ = x }", 0)
+		if err != nil {
+			t.Fatalf(`exp nil err from ParseFile; got %v`, err)
+		}
+		if f.Scope == nil || len(f.Unresolved) == 0 {
+			t.Fatal(`exp parsed file to be resolved`)
+		}
+
+		var buf bytes.Buffer
+		if err := Encode(&buf, f); err != nil {
+			t.Fatalf(`exp nil err from Encode; got %v`, err)
+		}
+		if f.Scope == nil || f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Names[0].Obj == nil {
+			t.Fatal(`exp Encode to leave the objects of the node unchanged`)
+		}
+
+		node, err := Decode(&buf)
+		if err != nil {
+			t.Fatalf(`exp nil err from Decode; got %v`, err)
+		}
+		got := node.(*ast.File)
+		if got.Scope != nil || got.Unresolved != nil {
+			t.Fatal(`exp decoded file to have no scope or unresolved idents`)
+		}
+		ast.Inspect(got, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Obj != nil {
+				t.Fatalf(`exp nil Obj for decoded ident %v`, id.Name)
+			}
+			return true
+		})
+	})
+	t.Run(`Errors`, func(t *testing.T) {
+		if err := Encode(new(bytes.Buffer), nil); err == nil {
+			t.Fatal(`exp non-nil err encoding a nil node`)
+		}
+		if node, err := Decode(strings.NewReader(`garbage`)); err == nil {
+			t.Fatalf(`exp non-nil err decoding garbage; got %T`, node)
+		}
+	})
+}
@@ -0,0 +1,304 @@
+package astfrom
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"hash"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Equal reports whether a and b are structurally equal, the comparison which
+// Diff describes and Hash is consistent with. Positions and the object
+// resolution fields populated by go/parser (Obj, Scope and Unresolved) are
+// ignored, so the same source parsed twice, or parsed with differing
+// whitespace, will compare equal. Maps such as the Files of an *ast.Package
+// are compared by key, while maps of objects such as its Imports are ignored.
+func Equal(a, b ast.Node) bool {
+	return equal(a, b)
+}
+
+// equal implements Equal.
+func equal(a, b ast.Node) bool {
+	var c comparer
+	return c.compare(reflect.ValueOf(a), reflect.ValueOf(b), nil)
+}
+
+// Diff returns a human readable description of the structural differences
+// between a and b, one per line, or an empty string if they are Equal. Each
+// difference is prefixed by the path to the field from the root node, along
+// with the node type declaring it:
+//
+//	Rhs[0].Value (*ast.BasicLit): "1" != "2"
+func Diff(a, b ast.Node) string {
+	c := comparer{record: true}
+	c.compare(reflect.ValueOf(a), reflect.ValueOf(b), nil)
+	return strings.Join(c.diffs, "\n")
+}
+
+// Hash returns a structural hash of node which is consistent with Equal, so
+// nodes which are Equal always hash equally. The hash is stable across runs
+// and processes, making it suitable for deduplicating parsed snippets. Like
+// Equal it ignores positions, whitespace and object resolution.
+func Hash(node ast.Node) uint64 {
+	h := hasher{Hash64: fnv.New64a()}
+	h.hash(reflect.ValueOf(node))
+	return h.Sum64()
+}
+
+// hasher feeds each value visited by Equal into a hash, prefixing it with its
+// type and separating values so adjacent fields can't be confused.
+type hasher struct {
+	hash.Hash64
+	buf []byte
+}
+
+func (h *hasher) write(s string) {
+	h.buf = append(h.buf[:0], s...)
+	h.buf = append(h.buf, 0)
+	h.Write(h.buf)
+}
+
+func (h *hasher) hash(v reflect.Value) {
+	if !v.IsValid() {
+		h.write(`nil`)
+		return
+	}
+	h.write(v.Type().String())
+	if ignored(v.Type()) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			h.write(`nil`)
+			return
+		}
+		h.hash(v.Elem())
+	case reflect.Slice:
+		h.write(fmt.Sprint(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			h.hash(v.Index(i))
+		}
+	case reflect.Map:
+		keys := sortedKeys(v)
+		h.write(fmt.Sprint(len(keys)))
+		for _, key := range keys {
+			h.hash(key)
+			h.hash(v.MapIndex(key))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if ignoredField(f) {
+				continue
+			}
+			if presenceField(f) {
+				h.hash(reflect.ValueOf(v.Field(i).Int() != 0))
+				continue
+			}
+			h.hash(v.Field(i))
+		}
+	case reflect.String:
+		h.write(v.String())
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		h.write(fmt.Sprint(v.Interface()))
+	}
+}
+
+var (
+	posType   = reflect.TypeOf(token.NoPos)
+	objType   = reflect.TypeOf((*ast.Object)(nil))
+	scopeType = reflect.TypeOf((*ast.Scope)(nil))
+)
+
+// ignored reports whether a value of type typ is ignored during comparison.
+func ignored(typ reflect.Type) bool {
+	return typ == posType || typ == objType || typ == scopeType
+}
+
+// ignoredField reports whether the struct field f is ignored during comparison,
+// which includes maps of ignored values such as the Imports of an
+// *ast.Package.
+func ignoredField(f reflect.StructField) bool {
+	if f.Type.Kind() == reflect.Map && ignored(f.Type.Elem()) {
+		return true
+	}
+	return f.Name == `Unresolved` || (ignored(f.Type) && !presenceField(f))
+}
+
+// sortedKeys returns the keys of the map v sorted by their printed value, so
+// maps are visited in a stable order.
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
+
+// presenceField reports whether f is a position which carries meaning through
+// its presence, such as the Ellipsis of a *ast.CallExpr which is only valid for
+// variadic calls. Only the validity of such positions is compared.
+func presenceField(f reflect.StructField) bool {
+	return f.Type == posType && f.Name == `Ellipsis`
+}
+
+// step is a single step in the path from the root node to a value, the path
+// is only rendered when a difference is recorded.
+type step struct {
+	parent *step
+	name   string
+	owner  reflect.Type
+}
+
+func (s *step) String() string {
+	if s == nil {
+		return `.`
+	}
+	var names []string
+	for cur := s; cur != nil; cur = cur.parent {
+		names = append(names, cur.name)
+	}
+
+	var b strings.Builder
+	for i := len(names) - 1; i >= 0; i-- {
+		b.WriteString(names[i])
+	}
+	str := strings.TrimPrefix(b.String(), `.`)
+
+	for cur := s; cur != nil; cur = cur.parent {
+		if cur.owner != nil {
+			return str + ` (` + cur.owner.String() + `)`
+		}
+	}
+	return str
+}
+
+// comparer compares values, stopping at the first difference unless record is
+// set in which case each difference is added to diffs.
+type comparer struct {
+	record bool
+	diffs  []string
+}
+
+func (c *comparer) differ(path *step, a, b interface{}) bool {
+	if c.record {
+		c.diffs = append(c.diffs, fmt.Sprintf("%v: %v != %v", path, a, b))
+	}
+	return false
+}
+
+func (c *comparer) compare(a, b reflect.Value, path *step) bool {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() == b.IsValid() {
+			return true
+		}
+		return c.differ(path, describe(a), describe(b))
+	}
+	if a.Type() != b.Type() {
+		return c.differ(path, a.Type(), b.Type())
+	}
+	if ignored(a.Type()) {
+		return true
+	}
+
+	switch a.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() == b.IsNil() {
+				return true
+			}
+			return c.differ(path, describe(a), describe(b))
+		}
+		return c.compare(a.Elem(), b.Elem(), path)
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return c.differ(path, fmt.Sprintf("len %d", a.Len()), fmt.Sprintf("len %d", b.Len()))
+		}
+		eq := true
+		for i := 0; i < a.Len() && (eq || c.record); i++ {
+			next := &step{parent: path, name: fmt.Sprintf("[%d]", i)}
+			eq = c.compare(a.Index(i), b.Index(i), next) && eq
+		}
+		return eq
+	case reflect.Map:
+		ak, bk := sortedKeys(a), sortedKeys(b)
+		as, bs := keyStrings(ak), keyStrings(bk)
+		if !reflect.DeepEqual(as, bs) {
+			return c.differ(path, fmt.Sprintf("keys %q", as), fmt.Sprintf("keys %q", bs))
+		}
+		eq := true
+		for i := 0; i < len(ak) && (eq || c.record); i++ {
+			next := &step{parent: path, name: fmt.Sprintf("[%q]", as[i])}
+			eq = c.compare(a.MapIndex(ak[i]), b.MapIndex(bk[i]), next) && eq
+		}
+		return eq
+	case reflect.Struct:
+		eq := true
+		for i := 0; i < a.NumField() && (eq || c.record); i++ {
+			f := a.Type().Field(i)
+			if ignoredField(f) {
+				continue
+			}
+			next := &step{parent: path, name: `.` + f.Name, owner: reflect.PointerTo(a.Type())}
+			if presenceField(f) {
+				av, bv := reflect.ValueOf(a.Field(i).Int() != 0), reflect.ValueOf(b.Field(i).Int() != 0)
+				eq = c.compare(av, bv, next) && eq
+				continue
+			}
+			eq = c.compare(a.Field(i), b.Field(i), next) && eq
+		}
+		return eq
+	case reflect.String:
+		if a.String() == b.String() {
+			return true
+		}
+		return c.differ(path, fmt.Sprintf("%q", a.String()), fmt.Sprintf("%q", b.String()))
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return true
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if a.Int() == b.Int() {
+			return true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if a.Uint() == b.Uint() {
+			return true
+		}
+	}
+	return c.differ(path, describe(a), describe(b))
+}
+
+// keyStrings returns the printed value of each of keys.
+func keyStrings(keys []reflect.Value) []string {
+	strs := make([]string, len(keys))
+	for i, key := range keys {
+		strs[i] = fmt.Sprint(key.Interface())
+	}
+	return strs
+}
+
+// describe returns a short description of v for use within a difference.
+func describe(v reflect.Value) string {
+	switch {
+	case !v.IsValid():
+		return `nil`
+	case (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) && v.IsNil():
+		return `nil`
+	case v.Kind() == reflect.Interface:
+		return v.Elem().Type().String()
+	case v.Kind() == reflect.Ptr:
+		return v.Type().String()
+	case v.CanInterface():
+		return fmt.Sprint(v.Interface())
+	}
+	return v.String()
+}
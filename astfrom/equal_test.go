@@ -0,0 +1,184 @@
+package astfrom
+
+import (
+	"go/ast"
+	"strings"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	type test struct {
+		a, b string
+		exp  bool
+	}
+	tests := []test{
+		{`foo`, `foo`, true},
+		{`foo`, `bar`, false},
+		{`1 + 2`, `1+2`, true},
+		{`f(a...)`, `f(a...)`, true},
+		{`f(a...)`, `f(a)`, false},
+		{`1 + 2`, `1 - 2`, false},
+		{`foo := 42`, `foo   :=   42`, true},
+		{`foo := 42`, `foo = 42`, false},
+		{`if x { y() }`, "if x {\n\ty()\n}", true},
+		{`package p; var x = 1`, "package p\n\nvar x = 1\n", true},
+		{`package p; var x = 1`, `package q; var x = 1`, false},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - Equal(%q, %q) exp %v`, idx, test.a, test.b, test.exp)
+
+		a, b := Source(test.a), Source(test.b)
+		if exp, got := test.exp, Equal(a, b); exp != got {
+			t.Fatalf(`exp Equal to return %v; got %v`, exp, got)
+		}
+		if exp, got := test.exp, Equal(b, a); exp != got {
+			t.Fatalf(`exp Equal to be symmetric`)
+		}
+	}
+
+	t.Run(`Nil`, func(t *testing.T) {
+		if !Equal(nil, nil) {
+			t.Fatal(`exp nil nodes to be equal`)
+		}
+		if Equal(nil, ast.NewIdent(`foo`)) {
+			t.Fatal(`exp nil and non-nil nodes to differ`)
+		}
+		if Equal((*ast.Ident)(nil), ast.NewIdent(`foo`)) {
+			t.Fatal(`exp nil and non-nil nodes to differ`)
+		}
+	})
+}
+
+func TestHash(t *testing.T) {
+	type test struct {
+		a, b string
+		exp  bool
+	}
+	tests := []test{
+		{`foo`, `foo`, true},
+		{`foo`, `bar`, false},
+		{`1 + 2`, `1+2`, true},
+		{`1 + 2`, `1 - 2`, false},
+		{`ab + c`, `a + bc`, false},
+		{`f(a, b)`, `f(a)(b)`, false},
+		{`f(a...)`, `f(a...)`, true},
+		{`f(a...)`, `f(a)`, false},
+		{`"1"`, `1`, false},
+		{`x`, `(x)`, false},
+		{`[]int{}`, `[]int{}`, true},
+		{`[]int{}`, `[]int(nil)`, false},
+		{`foo := 42`, `foo   :=   42`, true},
+		{`foo := 42`, `foo = 42`, false},
+		{`if x { y() }`, "if x {\n\ty()\n}", true},
+		{"func f() {\n\tx := 1\n\t_ = x\n}", "func f() { x := 1; _ = x }", true},
+		{`package p; var x = 1`, "package p\n\nvar x = 1\n", true},
+		{`package p; var x = 1`, `package q; var x = 1`, false},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - Hash(%q) == Hash(%q) exp %v`, idx, test.a, test.b, test.exp)
+
+		a, b := Source(test.a), Source(test.b)
+		if exp, got := Equal(a, b), test.exp; exp != got {
+			t.Fatalf(`exp Equal to return %v; got %v`, exp, got)
+		}
+		if exp, got := test.exp, Hash(a) == Hash(b); exp != got {
+			t.Fatalf(`exp equal hashes %v; got %v (%x, %x)`, exp, got, Hash(a), Hash(b))
+		}
+		if exp, got := Hash(a), Hash(Source(test.a)); exp != got {
+			t.Fatalf(`exp stable hash %x; got %x`, exp, got)
+		}
+	}
+
+	t.Run(`Dedup`, func(t *testing.T) {
+		seen := make(map[uint64]string)
+		for _, src := range []string{`a + b`, `a+b`, `a - b`, ` a  +  b `, `(a + b)`, `a - b`} {
+			if _, ok := seen[Hash(Source(src))]; !ok {
+				seen[Hash(Source(src))] = src
+			}
+		}
+		if exp, got := 3, len(seen); exp != got {
+			t.Fatalf(`exp %v unique snippets; got %v: %v`, exp, got, seen)
+		}
+	})
+
+	t.Run(`Nil`, func(t *testing.T) {
+		if exp, got := Hash(nil), Hash(nil); exp != got {
+			t.Fatal(`exp nil nodes to hash equally`)
+		}
+		if Hash(nil) == Hash(ast.NewIdent(``)) {
+			t.Fatal(`exp nil and non-nil nodes to hash differently`)
+		}
+		if Hash((*ast.Ident)(nil)) == Hash(ast.NewIdent(``)) {
+			t.Fatal(`exp nil and non-nil nodes to hash differently`)
+		}
+	})
+}
+
+func TestDiff(t *testing.T) {
+	type test struct {
+		a, b string
+		exp  []string
+	}
+	tests := []test{
+		{`foo`, `foo`, nil},
+		{`foo := 42`, `foo   :=   42`, nil},
+		{`foo`, `bar`, []string{`Name (*ast.Ident): "foo" != "bar"`}},
+		{`foo`, `42`, []string{`.: *ast.Ident != *ast.BasicLit`}},
+		{`x := 1`, `x = 2`, []string{
+			`Tok (*ast.AssignStmt): := != =`,
+			`Rhs[0].Value (*ast.BasicLit): "1" != "2"`,
+		}},
+		{`f(a, b)`, `f(a)`, []string{`Args (*ast.CallExpr): len 2 != len 1`}},
+		{`f(a, b)`, `f(a, 1)`, []string{`Args[1] (*ast.CallExpr): *ast.Ident != *ast.BasicLit`}},
+		{`f(a...)`, `f(a)`, []string{`Ellipsis (*ast.CallExpr): true != false`}},
+		{`return`, `return x`, []string{`Results (*ast.ReturnStmt): len 0 != len 1`}},
+		{`if x {}`, `if x {} else {}`, []string{`Else (*ast.IfStmt): nil != *ast.BlockStmt`}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - Diff(%q, %q) exp %q`, idx, test.a, test.b, test.exp)
+
+		a, b := Source(test.a), Source(test.b)
+		if exp, got := strings.Join(test.exp, "\n"), Diff(a, b); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+		if exp, got := len(test.exp) == 0, Equal(a, b); exp != got {
+			t.Fatalf(`exp Equal to return %v; got %v`, exp, got)
+		}
+	}
+
+	t.Run(`Package`, func(t *testing.T) {
+		pkg := func(srcs map[string]string) *ast.Package {
+			pkg, _, err := SourcePackage(srcs)
+			if err != nil {
+				t.Fatalf(`exp nil err from SourcePackage; got %v`, err)
+			}
+			return pkg
+		}
+		srcs := map[string]string{
+			`a.go`: "package p\n\nimport \"fmt\"\n\nfunc A() { fmt.Println() }",
+			`b.go`: "package p\n\nfunc B() { A() }",
+		}
+		a, b := pkg(srcs), pkg(srcs)
+		if !Equal(a, a) || !Equal(a, b) {
+			t.Fatalf("exp equal packages; got diff:\n%v", Diff(a, b))
+		}
+		if Hash(a) != Hash(b) {
+			t.Fatal(`exp equal packages to hash equally`)
+		}
+
+		srcs[`b.go`] = "package p\n\nfunc B() { A(1) }"
+		if exp, got := `Files["b.go"].Decls[0].Body.List[0].X.Args (*ast.CallExpr): len 0 != len 1`,
+			Diff(a, pkg(srcs)); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+		if Hash(a) == Hash(pkg(srcs)) {
+			t.Fatal(`exp differing packages to hash differently`)
+		}
+
+		srcs[`c.go`] = "package p"
+		if exp, got := `Files (*ast.Package): keys ["a.go" "b.go"] != keys ["a.go" "b.go" "c.go"]`,
+			Diff(a, pkg(srcs)); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	})
+}
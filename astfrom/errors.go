@@ -0,0 +1,79 @@
+package astfrom
+
+import (
+	"errors"
+)
+
+// ErrUnparseable is matched by errors.Is for the error returned when src could
+// not be parsed as any kind, meaning the heuristic was exhausted rather than
+// src containing a single syntax error at a known kind.
+var ErrUnparseable = errors.New("astfrom: source could not be parsed as any kind")
+
+// ErrElidedLit is returned from Check when src is a composite literal with its
+// type elided, such as `{1, 2}`, as the type of its elements is unknown.
+var ErrElidedLit = errors.New("astfrom: elided composite literal cannot be type checked")
+
+// errPkgSentinel is returned for a file in the sentinel package which lacks the
+// declarations of its scaffolding, as it would be reduced as if it were one.
+var errPkgSentinel = errors.New("astfrom: package name " + pkgSentinel + " collides with the scaffolding")
+
+// ParseError is returned from the entry points which climb the kinds, such as
+// SourceErr and Check, when every parse attempt failed. It matches
+// ErrUnparseable with errors.Is, while the error of each attempt is available
+// through Unwrap so errors.As may still retrieve a scanner.ErrorList.
+//
+// The positions reported by Error and Unwrap are relative to the source given
+// to the entry point, with positions within the scaffolding clamped to the
+// nearest end of it. The Err of each attempt is left relative to its Src.
+type ParseError struct {
+	// Attempts are each of the failed parse attempts in the order they were
+	// made, which is never empty.
+	Attempts []Attempt
+
+	m *sourceMap
+}
+
+// Error returns the error of the attempt at the largest kind, which parsed src
+// with the fewest assumptions about its kind.
+func (e *ParseError) Error() string {
+	last := e.Attempts[0]
+	for _, a := range e.Attempts[1:] {
+		if a.Kind > last.Kind {
+			last = a
+		}
+	}
+	return e.m.mapErr(last.Err, last).Error()
+}
+
+// specific returns the mapped error of the most specific attempt, which is the
+// one parsing furthest into src before failing. Ties are won by the smaller
+// kind, which made the most assumptions about src.
+func (e *ParseError) specific() error {
+	var (
+		best error
+		kind Kind
+		far  = -2
+	)
+	for _, a := range e.Attempts {
+		err := e.m.mapErr(a.Err, a)
+		off := errOffset(err)
+		if off > far || (off == far && a.Kind < kind) {
+			best, kind, far = err, a.Kind, off
+		}
+	}
+	return best
+}
+
+// Is reports whether target is ErrUnparseable.
+func (e *ParseError) Is(target error) bool {
+	return target == ErrUnparseable
+}
+
+// Unwrap returns the error of each attempt.
+func (e *ParseError) Unwrap() []error {
+	errs := make([]error, len(e.Attempts))
+	for idx, a := range e.Attempts {
+		errs[idx] = e.m.mapErr(a.Err, a)
+	}
+	return errs
+}
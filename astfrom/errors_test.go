@@ -0,0 +1,71 @@
+package astfrom
+
+import (
+	"errors"
+	"go/scanner"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	for idx, src := range []string{`{`, `x :=`, `func {`, `)(`, "package main\nfunc {"} {
+		t.Logf(`test #%v - from src %q exp ErrUnparseable`, idx, src)
+
+		_, err := SourceErr(src)
+		if !errors.Is(err, ErrUnparseable) {
+			t.Fatalf(`exp err to match ErrUnparseable; got %v`, err)
+		}
+
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Fatalf(`exp *ParseError; got %T`, err)
+		}
+		if exp, got := 6, len(perr.Attempts); exp != got {
+			t.Fatalf(`exp %v attempts; got %v`, exp, got)
+		}
+		for i, a := range perr.Attempts {
+			if exp, got := KindExpr+Kind(i), a.Kind; exp != got {
+				t.Fatalf(`exp attempt #%v of kind %v; got %v`, i, exp, got)
+			}
+			if a.Err == nil {
+				t.Fatalf(`exp attempt #%v to have failed`, i)
+			}
+		}
+		if exp, got := perr.Unwrap()[5].Error(), err.Error(); exp != got {
+			t.Fatalf(`exp error of final attempt %q; got %q`, exp, got)
+		}
+		if exp, got := len(perr.Attempts), len(perr.Unwrap()); exp != got {
+			t.Fatalf(`exp %v unwrapped errors; got %v`, exp, got)
+		}
+
+		var list scanner.ErrorList
+		if !errors.As(err, &list) || len(list) == 0 {
+			t.Fatalf(`exp scanner.ErrorList through Unwrap; got %v`, list)
+		}
+	}
+
+	t.Run(`EntryPoints`, func(t *testing.T) {
+		if _, _, err := Check(`{`); !errors.Is(err, ErrUnparseable) {
+			t.Fatalf(`exp Check err to match ErrUnparseable; got %v`, err)
+		}
+		if _, err := SourceResult(`{`); !errors.Is(err, ErrUnparseable) {
+			t.Fatalf(`exp SourceResult err to match ErrUnparseable; got %v`, err)
+		}
+		if _, err := SourceFormatted(`{`); !errors.Is(err, ErrUnparseable) {
+			t.Fatalf(`exp SourceFormatted err to match ErrUnparseable; got %v`, err)
+		}
+		if _, err := SourceStmt(`{`); !errors.Is(err, ErrUnparseable) {
+			t.Fatalf(`exp SourceStmt err to match ErrUnparseable; got %v`, err)
+		}
+	})
+	t.Run(`Parseable`, func(t *testing.T) {
+		for _, src := range []string{`package main`, `package ` + pkgSentinel} {
+			_, err := SourceErr(src)
+			if errors.Is(err, ErrUnparseable) {
+				t.Fatalf(`exp err from SourceErr(%q) not to match ErrUnparseable`, src)
+			}
+		}
+		if _, err := SourceStmt(`func f() {}`); err == nil || errors.Is(err, ErrUnparseable) {
+			t.Fatalf(`exp SourceStmt err not to match ErrUnparseable; got %v`, err)
+		}
+	})
+}
@@ -47,3 +47,34 @@ func Example() {
 	// 	i := 0
 	// }
 }
+
+func ExampleSourceFormatted() {
+	for _, src := range []string{
+		`1+2`,
+		`func() {}`,
+		`x:=[]int{1,2}`,
+		`var foo = "str"; i := 0`,
+	} {
+		out, err := astfrom.SourceFormatted(src)
+		if err != nil {
+			fmt.Println(`Error:`, err)
+		}
+		fmt.Printf("`%v` ->\n%s\n\n", src, out)
+	}
+
+	// Output:
+	// `1+2` ->
+	// 1 + 2
+	//
+	// `func() {}` ->
+	// func() {}
+	//
+	// `x:=[]int{1,2}` ->
+	// x := []int{1, 2}
+	//
+	// `var foo = "str"; i := 0` ->
+	// {
+	// 	var foo = "str"
+	// 	i := 0
+	// }
+}
@@ -0,0 +1,47 @@
+package astfrom
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// SourceExprList parses src as a comma separated list of expressions such as
+// `1, 2, foo()`, returning them in source order. A trailing comma is allowed
+// while empty elements are a syntax error, and empty source returns an empty
+// list.
+func SourceExprList(src string) ([]ast.Expr, error) {
+	if src = trimLines(src); len(src) == 0 {
+		return []ast.Expr{}, nil
+	}
+
+	var call *ast.CallExpr
+	err := recoverFn(func() error {
+		expr, err := parser.ParseExprFrom(
+			token.NewFileSet(), `string.go`, fnSentinelName+"("+src+")", 0)
+		if err != nil {
+			return err
+		}
+
+		// The sentinel call must span all of the source, otherwise src closed
+		// it early such as `a)(b`, which calls its result.
+		var ok bool
+		if call, ok = expr.(*ast.CallExpr); !ok || call.Rparen != expr.End()-1 {
+			return errors.New("expected expression list")
+		}
+		fun, ok := call.Fun.(*ast.Ident)
+		if !ok || fun.Name != fnSentinelName || fun.Pos() != expr.Pos() ||
+			call.Lparen != fun.End() {
+			return errors.New("expected expression list")
+		}
+		if call.Ellipsis.IsValid() {
+			return errors.New("unexpected ... in expression list")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return call.Args, nil
+}
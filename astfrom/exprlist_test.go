@@ -0,0 +1,50 @@
+package astfrom
+
+import (
+	"testing"
+)
+
+func TestSourceExprList(t *testing.T) {
+	type test struct {
+		src string
+		exp []string
+	}
+	tests := []test{
+		{``, []string{}},
+		{"  \n", []string{}},
+		{`foo`, []string{`foo`}},
+		{`1, 2, foo()`, []string{`1`, `2`, `foo()`}},
+		{`1, 2,`, []string{`1`, `2`}},
+		{"a,\n\tb + c,\n\t[]int{1, 2},\n", []string{`a`, `b + c`, `[]int{1, 2}`}},
+		{`func() {}, x.(T), <-ch`, []string{"func() {\n}", `x.(T)`, `<-ch`}},
+		{`"a,b", 'c', (d)`, []string{`"a,b"`, `'c'`, `(d)`}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.exp)
+
+		exprs, err := SourceExprList(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceExprList; got %v`, err)
+		}
+		if exp, got := len(test.exp), len(exprs); exp != got {
+			t.Fatalf(`exp %v exprs; got %v`, exp, got)
+		}
+		for i := range exprs {
+			if exp, got := test.exp[i], sprint(t, exprs[i]); exp != got {
+				t.Fatalf(`exp expr #%v to be %q; got %q`, i, exp, got)
+			}
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{
+			`1,,2`, `,`, `, 1`, `1, 2,,`, `x := 1`, `a) + f(b`, `a)(b`, `a)(b)(c`,
+			`a).f(b`, `xs...`, `{`,
+		} {
+			exprs, err := SourceExprList(src)
+			if err == nil {
+				t.Fatalf(`exp non-nil err from SourceExprList(%q); got %v`, src, exprs)
+			}
+		}
+	})
+}
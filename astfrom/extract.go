@@ -0,0 +1,228 @@
+package astfrom
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"strconv"
+)
+
+// CompositeElements returns the element values of the *ast.CompositeLit node
+// in source order, or nil if node is not a composite literal. The value of a
+// keyed element (*ast.KeyValueExpr) is returned in place of the element, use
+// CompositeKeys to retrieve the matching keys.
+func CompositeElements(node ast.Node) []ast.Expr {
+	lit, ok := node.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	elts := make([]ast.Expr, len(lit.Elts))
+	for idx, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			elt = kv.Value
+		}
+		elts[idx] = elt
+	}
+	return elts
+}
+
+// CompositeKeys returns the element keys of the *ast.CompositeLit node in
+// source order, or nil if node is not a composite literal. The key of an
+// unkeyed element is nil, so the result always lines up with the values
+// returned from CompositeElements.
+func CompositeKeys(node ast.Node) []ast.Expr {
+	lit, ok := node.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	keys := make([]ast.Expr, len(lit.Elts))
+	for idx, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			keys[idx] = kv.Key
+		}
+	}
+	return keys
+}
+
+// Specs returns the specs of the *ast.GenDecl node in source order, or nil if
+// node is not a general declaration. Each spec of a grouped declaration such
+// as `var ( a = 1; b = 2 )` is returned as its own entry, an *ast.ImportSpec,
+// *ast.ValueSpec or *ast.TypeSpec depending on the Tok of the declaration.
+// Local declarations wrapped in an *ast.DeclStmt are also accepted.
+func Specs(node ast.Node) []ast.Spec {
+	if stmt, ok := node.(*ast.DeclStmt); ok {
+		node = stmt.Decl
+	}
+	decl, ok := node.(*ast.GenDecl)
+	if !ok {
+		return nil
+	}
+	specs := make([]ast.Spec, len(decl.Specs))
+	copy(specs, decl.Specs)
+	return specs
+}
+
+// ConstSpecs returns the value specs of the const *ast.GenDecl node in source
+// order, or nil if node is not a const declaration.
+//
+// Specs after the first within a group may omit both their type and values, in
+// which case their Values are empty and Type is nil. Go treats each such spec
+// as repeating the type and values of the closest preceding spec which has
+// them, evaluated with the value of iota for its own position in the group:
+//
+//	const (
+//		a = iota // Values: [iota]
+//		b        // Values: [], implicitly iota with iota == 1
+//		c        // Values: [], implicitly iota with iota == 2
+//	)
+func ConstSpecs(node ast.Node) []*ast.ValueSpec {
+	decl, ok := node.(*ast.GenDecl)
+	if !ok || decl.Tok != token.CONST {
+		return nil
+	}
+	specs := make([]*ast.ValueSpec, 0, len(decl.Specs))
+	for _, spec := range decl.Specs {
+		if vs, ok := spec.(*ast.ValueSpec); ok {
+			specs = append(specs, vs)
+		}
+	}
+	return specs
+}
+
+// StructTags returns the unquoted tag of each tagged field of the struct type
+// node, keyed by field name, or nil if node is not a struct. The node may be an
+// *ast.StructType, or an *ast.TypeSpec or type *ast.GenDecl declaring one, in
+// which case the first struct declared is used. Each name of a field declaring
+// multiple names such as `X, Y int` maps to the same tag, while embedded fields
+// are keyed by their unqualified type name, as with `Reader` for `*io.Reader`.
+// Fields without a tag are omitted.
+func StructTags(node ast.Node) map[string]string {
+	st := structType(node)
+	if st == nil {
+		return nil
+	}
+	tags := make(map[string]string)
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		for _, name := range field.Names {
+			tags[name.Name] = tag
+		}
+		if len(field.Names) == 0 {
+			if name := embeddedName(field.Type); name != `` {
+				tags[name] = tag
+			}
+		}
+	}
+	return tags
+}
+
+// structType returns the struct type of node as described by StructTags.
+func structType(node ast.Node) *ast.StructType {
+	switch T := node.(type) {
+	case *ast.StructType:
+		return T
+	case *ast.TypeSpec:
+		st, _ := T.Type.(*ast.StructType)
+		return st
+	case *ast.GenDecl:
+		for _, spec := range T.Specs {
+			if st := structType(spec); st != nil {
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// embeddedName returns the field name of an embedded field of type x.
+func embeddedName(x ast.Expr) string {
+	switch T := x.(type) {
+	case *ast.Ident:
+		return T.Name
+	case *ast.SelectorExpr:
+		return T.Sel.Name
+	case *ast.StarExpr:
+		return embeddedName(T.X)
+	case *ast.IndexExpr:
+		return embeddedName(T.X)
+	case *ast.IndexListExpr:
+		return embeddedName(T.X)
+	}
+	return ``
+}
+
+// FuncBody returns the body of the *ast.FuncLit or *ast.FuncDecl node. The
+// func literal of an immediately invoked call such as `func() { ... }()`, or
+// of a `go` or `defer` statement, is also accepted. An error is returned for
+// any other node, or for a declaration without a body such as one implemented
+// in assembly.
+func FuncBody(node ast.Node) (*ast.BlockStmt, error) {
+	switch T := node.(type) {
+	case *ast.GoStmt:
+		return FuncBody(T.Call)
+	case *ast.DeferStmt:
+		return FuncBody(T.Call)
+	case *ast.ExprStmt:
+		if call, ok := T.X.(*ast.CallExpr); ok {
+			return FuncBody(call)
+		}
+	case *ast.CallExpr:
+		if lit, ok := T.Fun.(*ast.FuncLit); ok {
+			return lit.Body, nil
+		}
+	case *ast.FuncLit:
+		return T.Body, nil
+	case *ast.FuncDecl:
+		if T.Body == nil {
+			return nil, fmt.Errorf("func %v has no body", T.Name.Name)
+		}
+		return T.Body, nil
+	}
+	return nil, fmt.Errorf("expected *ast.FuncLit or *ast.FuncDecl, found %T", node)
+}
+
+// LitValue returns the Go value of the *ast.BasicLit node, which is an int64
+// for INT, float64 for FLOAT, complex128 for IMAG, rune for CHAR and string for
+// STRING literals. All literal forms accepted by the Go spec are supported,
+// including hex, octal and binary integers, hex floats, digit separators and
+// raw strings. An error is returned if node is not a basic literal, is
+// malformed or is an integer which overflows an int64.
+func LitValue(node ast.Node) (interface{}, error) {
+	lit, ok := node.(*ast.BasicLit)
+	if !ok {
+		return nil, fmt.Errorf("expected *ast.BasicLit, found %T", node)
+	}
+
+	val := constant.MakeFromLiteral(lit.Value, lit.Kind, 0)
+	if val.Kind() == constant.Unknown {
+		return nil, fmt.Errorf("malformed %v literal %v", lit.Kind, lit.Value)
+	}
+
+	switch lit.Kind {
+	case token.INT, token.CHAR:
+		v, exact := constant.Int64Val(val)
+		if !exact {
+			return nil, fmt.Errorf("%v literal %v overflows int64", lit.Kind, lit.Value)
+		}
+		if lit.Kind == token.CHAR {
+			return rune(v), nil
+		}
+		return v, nil
+	case token.FLOAT:
+		v, _ := constant.Float64Val(val)
+		return v, nil
+	case token.IMAG:
+		re, _ := constant.Float64Val(constant.Real(val))
+		im, _ := constant.Float64Val(constant.Imag(val))
+		return complex(re, im), nil
+	default:
+		return constant.StringVal(val), nil
+	}
+}
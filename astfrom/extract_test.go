@@ -0,0 +1,419 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompositeElements(t *testing.T) {
+	type test struct {
+		src  string
+		exp  []string
+		keys []string
+	}
+	tests := []test{
+		{`T{}`, []string{}, []string{}},
+		{`T{1, 2}`, []string{`1`, `2`}, []string{``, ``}},
+		{`[]int{1}`, []string{`1`}, []string{``}},
+		{`[...]int{2: a, b}`, []string{`a`, `b`}, []string{`2`, ``}},
+		{`map[string]int{"a": 1}`, []string{`1`}, []string{`"a"`}},
+		{`struct{X int}{1}`, []string{`1`}, []string{``}},
+		{`T{X: 1, Y: y}`, []string{`1`, `y`}, []string{`X`, `Y`}},
+		{`[][]int{{1}, {2, 3}}`, []string{`{1}`, `{2, 3}`}, []string{``, ``}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.exp)
+
+		node := Source(test.src)
+		if exp, got := KindExpr, KindOf(node); exp != got {
+			t.Fatalf(`exp KindOf to return %v; got %v`, exp, got)
+		}
+
+		elts, keys := CompositeElements(node), CompositeKeys(node)
+		if exp, got := len(test.exp), len(elts); exp != got {
+			t.Fatalf(`exp %v elements; got %v`, exp, got)
+		}
+		if exp, got := len(test.keys), len(keys); exp != got {
+			t.Fatalf(`exp %v keys; got %v`, exp, got)
+		}
+		for i := range elts {
+			if exp, got := test.exp[i], sprint(t, elts[i]); exp != got {
+				t.Fatalf(`exp element #%v to be %q; got %q`, i, exp, got)
+			}
+			if exp, got := test.keys[i], sprint(t, keys[i]); exp != got {
+				t.Fatalf(`exp key #%v to be %q; got %q`, i, exp, got)
+			}
+		}
+	}
+
+	t.Run(`Nested`, func(t *testing.T) {
+		outer := CompositeElements(Source(`[][]int{{1}, {2, 3}}`))
+		inner := CompositeElements(outer[1])
+		if exp, got := 2, len(inner); exp != got {
+			t.Fatalf(`exp %v nested elements; got %v`, exp, got)
+		}
+		if exp, got := `3`, sprint(t, inner[1]); exp != got {
+			t.Fatalf(`exp nested element to be %q; got %q`, exp, got)
+		}
+	})
+	t.Run(`NotComposite`, func(t *testing.T) {
+		if got := CompositeElements(Source(`foo`)); got != nil {
+			t.Fatalf(`exp nil elements; got %v`, got)
+		}
+		if got := CompositeKeys(Source(`foo`)); got != nil {
+			t.Fatalf(`exp nil keys; got %v`, got)
+		}
+	})
+}
+
+func TestKindOf(t *testing.T) {
+	type test struct {
+		node ast.Node
+		exp  Kind
+	}
+	tests := []test{
+		{nil, KindNode},
+		{&ast.Ident{}, KindExpr},
+		{&ast.CompositeLit{}, KindExpr},
+		{&ast.FuncLit{}, KindExpr},
+		{&ast.AssignStmt{}, KindDecl},
+		{&ast.BlockStmt{}, KindDecl},
+		{&ast.GenDecl{Tok: token.VAR}, KindDecl},
+		{&ast.GenDecl{Tok: token.IMPORT}, KindFile},
+		{&ast.FuncDecl{}, KindFile},
+		{&ast.File{}, KindPkg},
+		{&ast.Field{}, KindNode},
+		{&ast.ValueSpec{}, KindNode},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - exp %v from node %T`, idx, test.exp, test.node)
+		if exp, got := test.exp, KindOf(test.node); exp != got {
+			t.Fatalf(`exp KindOf to return %v; got %v`, exp, got)
+		}
+	}
+
+	t.Run(`Parse`, func(t *testing.T) {
+		for _, src := range []string{
+			`x`, `f()`, `x := 1`, `return`, `{ f(); g() }`, `var x = 1`,
+			`type T int`, `import "fmt"`, `func f() {}`, `package p`,
+		} {
+			res := Parse(src)
+			if exp, got := res.Kind, KindOf(res.Node); exp != got {
+				t.Fatalf(`exp KindOf %T from %q to return %v; got %v`, res.Node, src, exp, got)
+			}
+		}
+	})
+}
+
+func TestPlacementKinds(t *testing.T) {
+	type test struct {
+		src string
+		exp []Kind
+	}
+	tests := []test{
+		{`x`, []Kind{KindExpr}},
+		{`x + y`, []Kind{KindExpr}},
+		{`f(x)`, []Kind{KindExpr, KindDecl, KindStmt}},
+		{`int(x)`, []Kind{KindExpr, KindDecl, KindStmt}},
+		{`<-ch`, []Kind{KindExpr, KindDecl, KindStmt}},
+		{`(<-ch)`, []Kind{KindExpr, KindDecl, KindStmt}},
+		{`func() {}`, []Kind{KindExpr}},
+		{`x := 1`, []Kind{KindDecl, KindStmt}},
+		{`return x`, []Kind{KindDecl, KindStmt}},
+		{`{ f(); g() }`, []Kind{KindDecl, KindStmt, KindBlock}},
+		{`var x = 1`, []Kind{KindDecl, KindStmt, KindFile, KindPkg}},
+		{`type T int`, []Kind{KindDecl, KindStmt, KindFile, KindPkg}},
+		{`import "fmt"`, []Kind{KindFile, KindPkg}},
+		{`func f() {}`, []Kind{KindFile, KindPkg}},
+		{`package p`, []Kind{KindPkg}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - exp %v from src %q`, idx, test.exp, test.src)
+
+		node := Source(test.src)
+		if exp, got := test.exp, PlacementKinds(node); !reflect.DeepEqual(exp, got) {
+			t.Fatalf(`exp PlacementKinds of %T to return %v; got %v`, node, exp, got)
+		}
+	}
+	for _, node := range []ast.Node{nil, &ast.Field{}, &ast.ValueSpec{}, &ast.Comment{}} {
+		if got := PlacementKinds(node); got != nil {
+			t.Fatalf(`exp nil from PlacementKinds of %T; got %v`, node, got)
+		}
+	}
+}
+
+func TestSpecs(t *testing.T) {
+	type test struct {
+		src string
+		exp []string
+		typ reflect.Type
+	}
+	var (
+		valueSpec  = reflect.TypeOf(&ast.ValueSpec{})
+		typeSpec   = reflect.TypeOf(&ast.TypeSpec{})
+		importSpec = reflect.TypeOf(&ast.ImportSpec{})
+	)
+	tests := []test{
+		{`var x = 1`, []string{`x = 1`}, valueSpec},
+		{`var ( a = 1; b = 2 )`, []string{`a = 1`, `b = 2`}, valueSpec},
+		{"var (\n\ta, b int\n\tc = \"c\"\n)", []string{`a, b int`, `c = "c"`}, valueSpec},
+		{"const (\n\tA = iota\n\tB\n\tC\n)", []string{`A = iota`, `B`, `C`}, valueSpec},
+		{"type (\n\tT int\n\tU = T\n\tV[E any] []E\n)", []string{`T int`, `U = T`, `V[E any] []E`}, typeSpec},
+		{"import (\n\t\"fmt\"\n\tx \"os\"\n\t_ \"embed\"\n)", []string{`"fmt"`, `x "os"`, `_ "embed"`}, importSpec},
+		{`import "fmt"`, []string{`"fmt"`}, importSpec},
+		{`var ()`, []string{}, nil},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.exp)
+
+		specs := Specs(Source(test.src))
+		if specs == nil {
+			t.Fatal(`exp non-nil specs from Specs`)
+		}
+		if exp, got := len(test.exp), len(specs); exp != got {
+			t.Fatalf(`exp %v specs; got %v`, exp, got)
+		}
+		for i, spec := range specs {
+			if exp, got := test.typ, reflect.TypeOf(spec); exp != got {
+				t.Fatalf(`exp spec #%v of type %v; got %v`, i, exp, got)
+			}
+			if exp, got := test.exp[i], sprint(t, spec); exp != got {
+				t.Fatalf(`exp spec #%v to be %q; got %q`, i, exp, got)
+			}
+		}
+	}
+
+	t.Run(`DeclStmt`, func(t *testing.T) {
+		stmt := &ast.DeclStmt{Decl: Source(`var ( a = 1; b = 2 )`).(*ast.GenDecl)}
+		if exp, got := 2, len(Specs(stmt)); exp != got {
+			t.Fatalf(`exp %v specs; got %v`, exp, got)
+		}
+	})
+	t.Run(`NotGenDecl`, func(t *testing.T) {
+		for _, src := range []string{`x`, `x := 1`, `func f() {}`, `package p; var x = 1`} {
+			if got := Specs(Source(src)); got != nil {
+				t.Fatalf(`exp nil from Specs(%q); got %v`, src, got)
+			}
+		}
+	})
+}
+
+func TestConstSpecs(t *testing.T) {
+	type spec struct {
+		names  string
+		typ    string
+		values int
+	}
+	type test struct {
+		src string
+		exp []spec
+	}
+	tests := []test{
+		{`const a = 1`, []spec{{`a`, ``, 1}}},
+		{`const a, b = 1, 2`, []spec{{`a,b`, ``, 2}}},
+		{`const ( a = iota; b; c )`, []spec{{`a`, ``, 1}, {`b`, ``, 0}, {`c`, ``, 0}}},
+		{"const (\n\tA T = iota + 1\n\tB\n\t_\n\tD = 10\n)",
+			[]spec{{`A`, `T`, 1}, {`B`, ``, 0}, {`_`, ``, 0}, {`D`, ``, 1}}},
+		{"package p\n\nconst (\n\tx, y = iota, -iota\n\tz, w\n)",
+			[]spec{{`x,y`, ``, 2}, {`z,w`, ``, 0}}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		node := Source(test.src)
+		if file, ok := node.(*ast.File); ok {
+			node = file.Decls[0]
+		}
+
+		specs := ConstSpecs(node)
+		if exp, got := len(test.exp), len(specs); exp != got {
+			t.Fatalf(`exp %v specs; got %v`, exp, got)
+		}
+		for i, exp := range test.exp {
+			var names []string
+			for _, name := range specs[i].Names {
+				names = append(names, name.Name)
+			}
+			if got := strings.Join(names, `,`); exp.names != got {
+				t.Fatalf(`exp spec #%v names %q; got %q`, i, exp.names, got)
+			}
+			if got := sprint(t, specs[i].Type); exp.typ != got {
+				t.Fatalf(`exp spec #%v type %q; got %q`, i, exp.typ, got)
+			}
+			if got := len(specs[i].Values); exp.values != got {
+				t.Fatalf(`exp spec #%v to have %v values; got %v`, i, exp.values, got)
+			}
+		}
+	}
+
+	t.Run(`NotConst`, func(t *testing.T) {
+		for _, src := range []string{`var a = 1`, `type T int`, `a := 1`, `iota`} {
+			if got := ConstSpecs(Source(src)); got != nil {
+				t.Fatalf(`exp nil specs from %q; got %v`, src, got)
+			}
+		}
+	})
+}
+
+func TestLitValue(t *testing.T) {
+	type test struct {
+		src string
+		exp interface{}
+	}
+	tests := []test{
+		{`42`, int64(42)},
+		{`0x2A`, int64(42)},
+		{`0X_2a`, int64(42)},
+		{`052`, int64(42)},
+		{`0o52`, int64(42)},
+		{`0b101010`, int64(42)},
+		{`1_000_000`, int64(1000000)},
+		{`9223372036854775807`, int64(9223372036854775807)},
+		{`1.5`, 1.5},
+		{`.25`, 0.25},
+		{`1e3`, 1000.0},
+		{`1_0.5e-1`, 1.05},
+		{`0x1p-2`, 0.25},
+		{`2i`, complex(0, 2)},
+		{`1.5i`, complex(0, 1.5)},
+		{`0x10i`, complex(0, 16)},
+		{`'a'`, 'a'},
+		{`'\n'`, '\n'},
+		{`'\x41'`, 'A'},
+		{`'é'`, 'é'},
+		{`'世'`, '世'},
+		{`"foo"`, `foo`},
+		{`"a\tbé"`, "a\tbé"},
+		{"`raw\\n`", `raw\n`},
+		{`""`, ``},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %#v`, idx, test.src, test.exp)
+
+		got, err := LitValue(Source(test.src))
+		if err != nil {
+			t.Fatalf(`exp nil err from LitValue; got %v`, err)
+		}
+		if exp := test.exp; exp != got {
+			t.Fatalf(`exp LitValue to return %#v (%[1]T); got %#v (%[2]T)`, exp, got)
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, node := range []ast.Node{
+			nil,
+			Source(`foo`),
+			Source(`-1`),
+			Source(`9223372036854775808`),
+			&ast.BasicLit{Kind: token.INT, Value: `0x`},
+			&ast.BasicLit{Kind: token.STRING, Value: `"unterminated`},
+		} {
+			if got, err := LitValue(node); err == nil {
+				t.Fatalf(`exp non-nil err from LitValue(%v); got %#v`, sprint(t, node), got)
+			}
+		}
+	})
+}
+
+func TestStructTags(t *testing.T) {
+	type test struct {
+		src string
+		exp map[string]string
+	}
+	tests := []test{
+		{`struct{}`, map[string]string{}},
+		{`struct{ X int }`, map[string]string{}},
+		{"struct{ X int `json:\"x\"` }", map[string]string{`X`: `json:"x"`}},
+		{"struct{ X int `json:\"x\"`; Y string }", map[string]string{`X`: `json:"x"`}},
+		{"struct{ X, Y int `db:\"xy\"` }", map[string]string{`X`: `db:"xy"`, `Y`: `db:"xy"`}},
+		{`struct{ X int "quoted" }`, map[string]string{`X`: `quoted`}},
+		{"struct{ io.Reader `a:\"1\"`; *T `b:\"2\"`; *pkg.U `c:\"3\"`; G[int] `d:\"4\"`; H[int, string] `e:\"5\"` }",
+			map[string]string{`Reader`: `a:"1"`, `T`: `b:"2"`, `U`: `c:"3"`, `G`: `d:"4"`, `H`: `e:"5"`}},
+		{"type T struct {\n\tName string `json:\"name,omitempty\" db:\"name\"`\n\tAge  int    `json:\"age\"`\n}",
+			map[string]string{`Name`: `json:"name,omitempty" db:"name"`, `Age`: `json:"age"`}},
+		{"type (\n\tA int\n\tB struct{ X int `x` }\n)", map[string]string{`X`: `x`}},
+		{"struct{ X struct{ Y int `y` } `x` }", map[string]string{`X`: `x`}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		got := StructTags(Source(test.src))
+		if got == nil {
+			t.Fatal(`exp non-nil map from StructTags`)
+		}
+		if exp := test.exp; !reflect.DeepEqual(exp, got) {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`TypeSpec`, func(t *testing.T) {
+		decl := Source("type T struct{ X int `x` }").(*ast.GenDecl)
+		exp := map[string]string{`X`: `x`}
+		if got := StructTags(decl.Specs[0]); !reflect.DeepEqual(exp, got) {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	})
+
+	t.Run(`NotStruct`, func(t *testing.T) {
+		for _, src := range []string{`x`, `T{}`, `type T int`, `var x struct{}`, `interface{}`} {
+			if got := StructTags(Source(src)); got != nil {
+				t.Fatalf(`exp nil from StructTags(%q); got %v`, src, got)
+			}
+		}
+	})
+}
+
+func TestFuncBody(t *testing.T) {
+	type test struct {
+		src  string
+		exp  ast.Node
+		body string
+	}
+	tests := []test{
+		{`func() {}`, &ast.FuncLit{}, "{\n}"},
+		{`func() { work() }`, &ast.FuncLit{}, "{\n\twork()\n}"},
+		{`func(x int) int { return x * 2 }`, &ast.FuncLit{}, "{\n\treturn x * 2\n}"},
+		{`func() { work() }()`, &ast.CallExpr{}, "{\n\twork()\n}"},
+		{`func(v int) { use(v) }(1)`, &ast.CallExpr{}, "{\n\tuse(v)\n}"},
+		{`go func() { work() }()`, &ast.GoStmt{}, "{\n\twork()\n}"},
+		{`defer func() { recover() }()`, &ast.DeferStmt{}, "{\n\trecover()\n}"},
+		{`func f() { work() }`, &ast.FuncDecl{}, "{\n\twork()\n}"},
+		{`func (T) M() { work(); done() }`, &ast.FuncDecl{}, "{\n\twork()\n\tdone()\n}"},
+		{"f := func() {\n\tg := func() { inner() }\n\tg()\n}", astAssign, ``},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T`, idx, test.src, test.exp)
+
+		node := Source(test.src)
+		expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(node)
+		if expTyp != gotTyp {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+		}
+		if test.body == `` {
+			node = node.(*ast.AssignStmt).Rhs[0]
+			test.body = "{\n\tg := func() {\n\t\tinner()\n\t}\n\tg()\n}"
+		}
+
+		body, err := FuncBody(node)
+		if err != nil {
+			t.Fatalf(`exp nil err from FuncBody; got %v`, err)
+		}
+		if exp, got := test.body, sprint(t, body); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{`f()`, `go work()`, `x := 1`, `func f()`, `type T func()`} {
+			if body, err := FuncBody(Source(src)); err == nil {
+				t.Fatalf(`exp non-nil err from FuncBody(%q); got %v`, src, sprint(t, body))
+			}
+		}
+		if _, err := FuncBody(nil); err == nil {
+			t.Fatal(`exp non-nil err from FuncBody(nil)`)
+		}
+	})
+}
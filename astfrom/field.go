@@ -0,0 +1,46 @@
+package astfrom
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// SourceField parses src as a single field of a parameter list, such as
+// `ctx context.Context`, `x, y int` or `args ...string`, by declaring it as the
+// sole parameter of the sentinel func. Unnamed fields such as `int` are
+// returned with no Names, while a variadic field has an *ast.Ellipsis Type. An
+// error is returned when src contains no fields or more than one, such as
+// `a int, b string`. A trailing comma and comment are allowed.
+func SourceField(src string) (*ast.Field, error) {
+	if src = strings.TrimSuffix(trimComment(src), ","); len(src) == 0 {
+		return nil, fmt.Errorf("expected field, found empty source")
+	}
+
+	prefix := "package " + pkgSentinel + "\n\nfunc " + fnSentinelName + "("
+	var field *ast.Field
+	err := recoverFn(func() error {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, `string.go`, prefix+src+",\n) {}\n", 0)
+		if err != nil {
+			return err
+		}
+
+		fn, ok := f.Decls[0].(*ast.FuncDecl)
+		if !ok || len(f.Decls) != 1 || fn.Body == nil || len(fn.Body.List) > 0 ||
+			fset.Position(fn.Type.Params.Closing).Offset != len(prefix)+len(src)+2 {
+			return fmt.Errorf("expected field")
+		}
+		if n := len(fn.Type.Params.List); n != 1 {
+			return fmt.Errorf("expected a single field, found %v", n)
+		}
+		field = fn.Type.Params.List[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return field, nil
+}
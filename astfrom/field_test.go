@@ -0,0 +1,60 @@
+package astfrom
+
+import (
+	"go/ast"
+	"testing"
+)
+
+func TestSourceField(t *testing.T) {
+	type test struct {
+		src      string
+		names    []string
+		typ      string
+		variadic bool
+	}
+	tests := []test{
+		{`ctx context.Context`, []string{`ctx`}, `context.Context`, false},
+		{`x, y int`, []string{`x`, `y`}, `int`, false},
+		{`int`, nil, `int`, false},
+		{`*T`, nil, `*T`, false},
+		{`args ...string`, []string{`args`}, `...string`, true},
+		{`...interface{}`, nil, `...interface{}`, true},
+		{`fn func(int) error`, []string{`fn`}, `func(int) error`, false},
+		{"\n\tm map[string][]int,\n", []string{`m`}, `map[string][]int`, false},
+		{`x int // comment`, []string{`x`}, `int`, false},
+		{`v T[K, V]`, []string{`v`}, `T[K, V]`, false},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v %v`, idx, test.src, test.names, test.typ)
+
+		field, err := SourceField(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceField; got %v`, err)
+		}
+		if exp, got := len(test.names), len(field.Names); exp != got {
+			t.Fatalf(`exp %v names; got %v`, exp, got)
+		}
+		for i, name := range field.Names {
+			if exp, got := test.names[i], name.Name; exp != got {
+				t.Fatalf(`exp name #%v to be %v; got %v`, i, exp, got)
+			}
+		}
+		if exp, got := test.typ, sprint(t, field.Type); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+		if _, ok := field.Type.(*ast.Ellipsis); ok != test.variadic {
+			t.Fatalf(`exp variadic %v; got %v`, test.variadic, ok)
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{
+			``, ` `, `,`, `x int,,`, `a int, b string`, `x, y`, `x int) {}; func f(y int`,
+			`x int) { g() }; func h(`, `x :=`, `(`, `x int = 1`,
+		} {
+			if field, err := SourceField(src); err == nil {
+				t.Fatalf(`exp non-nil err from SourceField(%q); got %v`, src, sprint(t, field.Type))
+			}
+		}
+	})
+}
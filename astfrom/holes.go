@@ -0,0 +1,94 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"strings"
+	"unicode/utf8"
+)
+
+// holePrefix is prepended to the name of each template hole identifier.
+const holePrefix = `astfromHole_`
+
+// Holes returns the identifiers created for the template holes within node
+// when parsed with Options.Placeholder, keyed by the name following the
+// placeholder. Each hole may occur any number of times and is returned in
+// source order, allowing real nodes to be substituted for them afterwards.
+func Holes(node ast.Node) map[string][]*ast.Ident {
+	holes := make(map[string][]*ast.Ident)
+	ast.Inspect(node, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && strings.HasPrefix(id.Name, holePrefix) {
+			name := id.Name[len(holePrefix):]
+			holes[name] = append(holes[name], id)
+		}
+		return true
+	})
+	return holes
+}
+
+// replaceHoles replaces each occurrence of o.Placeholder followed by an
+// identifier or keyword in src with holePrefix.
+func (o Options) replaceHoles(src string) string {
+	src, _ = o.replaceHoleEdits(src)
+	return src
+}
+
+// replaceHoleEdits behaves like replaceHoles, also returning each replacement
+// made in source order.
+func (o Options) replaceHoleEdits(src string) (string, []holeEdit) {
+	if o.Placeholder == 0 || !strings.ContainsRune(src, o.Placeholder) {
+		return src, nil
+	}
+
+	var (
+		b     strings.Builder
+		s     scanner.Scanner
+		edits []holeEdit
+		last  int
+		mark  = -1
+		fset  = token.NewFileSet()
+		file  = fset.AddFile(``, fset.Base(), len(src))
+	)
+	s.Init(file, []byte(src), func(token.Position, string) {}, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		off := file.Offset(pos)
+		name := tok == token.IDENT || tok.IsKeyword()
+		if name && mark >= 0 && mark+utf8.RuneLen(o.Placeholder) == off {
+			b.WriteString(src[last:mark])
+			edits = append(edits, holeEdit{
+				orig:  mark,
+				at:    b.Len(),
+				delta: len(holePrefix) - utf8.RuneLen(o.Placeholder),
+			})
+			b.WriteString(holePrefix)
+			last = off
+		}
+
+		mark = -1
+		if tok == token.ILLEGAL && lit == string(o.Placeholder) {
+			mark = off
+		}
+	}
+	b.WriteString(src[last:])
+	return b.String(), edits
+}
+
+// holeRune reports whether r may be used as Options.Placeholder, which is any
+// rune the scanner finds illegal on its own, such as '$' or '@', other than the
+// utf8.RuneError which invalid UTF-8 within src also decodes to.
+func holeRune(r rune) bool {
+	if !utf8.ValidRune(r) || r == utf8.RuneError {
+		return false
+	}
+	var s scanner.Scanner
+	src := []byte(string(r))
+	s.Init(token.NewFileSet().AddFile(``, -1, len(src)), src, func(token.Position, string) {}, 0)
+	_, tok, lit := s.Scan()
+	return tok == token.ILLEGAL && lit == string(r)
+}
@@ -0,0 +1,83 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestHoles(t *testing.T) {
+	type test struct {
+		src  string
+		ph   rune
+		exp  map[string]int
+		node ast.Node
+	}
+	tests := []test{
+		{`$name := $value`, '$', map[string]int{`name`: 1, `value`: 1}, astAssign},
+		{`$x + $x*$y`, '$', map[string]int{`x`: 2, `y`: 1}, &ast.BinaryExpr{}},
+		{`f("$x", $y) // $z`, '$', map[string]int{`y`: 1}, astCall},
+		{`@T{@v}`, '@', map[string]int{`T`: 1, `v`: 1}, &ast.CompositeLit{}},
+		{`func $name() {}`, '$', map[string]int{`name`: 1}, &ast.FuncDecl{}},
+		{`var x $type = $func($range)`, '$', map[string]int{`type`: 1, `func`: 1, `range`: 1}, astDecl},
+		{`x.(@type)`, '@', map[string]int{`type`: 1}, &ast.TypeAssertExpr{}},
+		{`f(x)`, '$', map[string]int{}, astCall},
+		{`f(x, $)`, '$', map[string]int{}, astExpr},
+		{`$x`, 0, map[string]int{}, astExpr},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q with placeholder %q exp %v`,
+			idx, test.src, test.ph, test.exp)
+
+		node := SourceWith(test.src, Options{Placeholder: test.ph})
+		if exp, got := KindOf(test.node), KindOf(node); exp != got {
+			t.Fatalf(`exp %v node; got %T`, exp, node)
+		}
+
+		holes := Holes(node)
+		if exp, got := len(test.exp), len(holes); exp != got {
+			t.Fatalf(`exp %v holes; got %v`, exp, got)
+		}
+		for name, exp := range test.exp {
+			ids := holes[name]
+			if got := len(ids); exp != got {
+				t.Fatalf(`exp %v holes named %v; got %v`, exp, name, got)
+			}
+			sorted := sort.SliceIsSorted(ids, func(i, j int) bool {
+				return ids[i].Pos() < ids[j].Pos()
+			})
+			if !sorted {
+				t.Fatalf(`exp holes named %v in source order`, name)
+			}
+			for _, id := range ids {
+				if !id.Pos().IsValid() {
+					t.Fatalf(`exp valid position for hole %v`, name)
+				}
+			}
+		}
+	}
+
+	t.Run(`Placeholder`, func(t *testing.T) {
+		for _, ph := range []rune{'_', 'a', 'é', '1', '+', '.', '(', '"', '`', '\'', '/', ' ', utf8.RuneError, -1} {
+			opts := Options{Placeholder: ph}
+			if _, err := opts.parse(token.NewFileSet(), `f(x)`, nil); err == nil {
+				t.Fatalf(`exp non-nil err for Placeholder %q`, ph)
+			}
+		}
+		for _, ph := range []rune{'$', '@', '#', '?', '§'} {
+			if !holeRune(ph) {
+				t.Fatalf(`exp Placeholder %q to be valid`, ph)
+			}
+		}
+	})
+	t.Run(`ReplaceHoles`, func(t *testing.T) {
+		opts := Options{Placeholder: '$'}
+		exp := `astfromHole_a := "$b" + astfromHole_c /* $d */ + $ + $astfromHole_e`
+		got := opts.replaceHoles(`$a := "$b" + $c /* $d */ + $ + $$e`)
+		if exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package astfrom
+
+import (
+	"go/ast"
+	"iter"
+	"reflect"
+)
+
+// All returns an iterator over node and each of its descendants in depth-first
+// order, the order nodes are visited by ast.Inspect. Breaking out of the range
+// loop stops the walk, leaving the remainder of the tree unvisited. A nil node
+// yields nothing.
+//
+//	for n := range astfrom.All(node) {
+//		if call, ok := n.(*ast.CallExpr); ok {
+//			...
+//		}
+//	}
+func All(node ast.Node) iter.Seq[ast.Node] {
+	return func(yield func(ast.Node) bool) {
+		if node == nil || reflect.ValueOf(node).IsNil() {
+			return
+		}
+		for n := range ast.Preorder(node) {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// Descendants returns an iterator like All which skips node itself, yielding
+// only its descendants.
+func Descendants(node ast.Node) iter.Seq[ast.Node] {
+	return func(yield func(ast.Node) bool) {
+		root := true
+		for n := range All(node) {
+			if root {
+				root = false
+				continue
+			}
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
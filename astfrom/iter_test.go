@@ -0,0 +1,79 @@
+package astfrom
+
+import (
+	"fmt"
+	"go/ast"
+	"iter"
+	"strings"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	type test struct {
+		src string
+		exp string
+	}
+	tests := []test{
+		{`x`, `*ast.Ident`},
+		{`a + b`, `*ast.BinaryExpr *ast.Ident *ast.Ident`},
+		{`f(1)`, `*ast.CallExpr *ast.Ident *ast.BasicLit`},
+		{`x := []int{1}`, `*ast.AssignStmt *ast.Ident *ast.CompositeLit *ast.ArrayType *ast.Ident *ast.BasicLit`},
+		{`if x { y() }`, `*ast.IfStmt *ast.Ident *ast.BlockStmt *ast.ExprStmt *ast.CallExpr *ast.Ident`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		node := Source(test.src)
+		var all, inspected, descendants []string
+		for n := range All(node) {
+			all = append(all, fmt.Sprintf(`%T`, n))
+		}
+		ast.Inspect(node, func(n ast.Node) bool {
+			if n != nil {
+				inspected = append(inspected, fmt.Sprintf(`%T`, n))
+			}
+			return true
+		})
+		for n := range Descendants(node) {
+			descendants = append(descendants, fmt.Sprintf(`%T`, n))
+		}
+
+		if exp, got := test.exp, strings.Join(all, ` `); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+		if exp, got := strings.Join(inspected, ` `), strings.Join(all, ` `); exp != got {
+			t.Fatalf("exp All to visit like ast.Inspect\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+		if exp, got := strings.Join(all[1:], ` `), strings.Join(descendants, ` `); exp != got {
+			t.Fatalf("exp Descendants to skip the root\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Break`, func(t *testing.T) {
+		node := Source("func f() {\n\ta()\n\tb()\n\tc()\n}")
+		for _, fn := range []func(ast.Node) iter.Seq[ast.Node]{All, Descendants} {
+			var names []string
+			for n := range fn(node) {
+				if id, ok := n.(*ast.Ident); ok {
+					names = append(names, id.Name)
+				}
+				if len(names) == 2 {
+					break
+				}
+			}
+			if exp, got := `f a`, strings.Join(names, ` `); exp != got {
+				t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+			}
+		}
+	})
+	t.Run(`Nil`, func(t *testing.T) {
+		for _, node := range []ast.Node{nil, (*ast.BinaryExpr)(nil)} {
+			for n := range All(node) {
+				t.Fatalf(`exp no nodes from All(%#v); got %T`, node, n)
+			}
+			for n := range Descendants(node) {
+				t.Fatalf(`exp no nodes from Descendants(%#v); got %T`, node, n)
+			}
+		}
+	})
+}
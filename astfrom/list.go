@@ -0,0 +1,100 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// List is the ordered list of nodes parsed from a single source.
+type List struct {
+	// Nodes are the reduced top-level nodes of the source in source order.
+	Nodes []ast.Node
+
+	// Fset is the FileSet shared by all of the Nodes, allowing the position of
+	// each node to be resolved within the parsed source.
+	Fset *token.FileSet
+
+	src string
+}
+
+// SourceList parses src like Source, but instead of returning a single block or
+// declaration for source containing multiple statements or declarations it
+// returns each of them individually.
+func SourceList(src string) (*List, error) {
+	var (
+		last Attempt
+		fset = token.NewFileSet()
+	)
+	node, err := trace(fset, src, func(a Attempt) {
+		last = a
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l := &List{Fset: fset, src: last.Src}
+	err = recoverFn(func() error {
+		l.Nodes = Options{}.list(node)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Len returns the number of nodes in the list.
+func (l *List) Len() int {
+	return len(l.Nodes)
+}
+
+// Sprint returns the original source text of the i'th node. A comment which
+// trails the node on its final line is included.
+func (l *List) Sprint(i int) string {
+	node := l.Nodes[i]
+	file := l.Fset.File(node.Pos())
+	if file == nil {
+		return ``
+	}
+	beg, end := file.Offset(node.Pos()), file.Offset(node.End())
+
+	line := l.src[end:]
+	if idx := strings.IndexByte(line, '\n'); idx >= 0 {
+		line = line[:idx]
+	}
+	rest := strings.TrimLeft(line, " \t;")
+	switch {
+	case strings.HasPrefix(rest, `//`):
+		end += len(line)
+	case strings.HasPrefix(rest, `/*`):
+		if idx := strings.Index(rest, `*/`); idx >= 0 {
+			end += len(line) - len(rest) + idx + len(`*/`)
+		}
+	}
+	return l.src[beg:end]
+}
+
+// list returns the nodes within the scaffolding of node in source order, each
+// individually reduced.
+func (o Options) list(node ast.Node) []ast.Node {
+	switch T := node.(type) {
+	case *ast.File:
+		if T.Name.Name == pkgSentinel {
+			var nodes []ast.Node
+			for _, decl := range o.decls(T) {
+				nodes = append(nodes, o.list(decl)...)
+			}
+			return nodes
+		}
+	case *ast.FuncDecl:
+		if T.Name.Name == fnSentinelName {
+			nodes := make([]ast.Node, len(T.Body.List))
+			for idx, stmt := range T.Body.List {
+				nodes[idx] = o.reduce(stmt)
+			}
+			return nodes
+		}
+	}
+	return []ast.Node{o.reduce(node)}
+}
@@ -0,0 +1,70 @@
+package astfrom
+
+import (
+	"go/ast"
+	"reflect"
+	"testing"
+)
+
+func TestSourceList(t *testing.T) {
+	type test struct {
+		src   string
+		exp   []string
+		types []ast.Node
+	}
+	tests := []test{
+		{`foo`, []string{`foo`}, []ast.Node{astExpr}},
+		{`x := 1`, []string{`x := 1`}, []ast.Node{astAssign}},
+		{`a := 1; b := 2 // comment`,
+			[]string{`a := 1`, `b := 2 // comment`},
+			[]ast.Node{astAssign, astAssign}},
+		{"a := 1 /* one */; b := 2\nc()",
+			[]string{`a := 1 /* one */`, `b := 2`, `c()`},
+			[]ast.Node{astAssign, astAssign, &ast.ExprStmt{}}},
+		{"if x {\n\ty()\n}\nvar z int // z\n",
+			[]string{"if x {\n\ty()\n}", `var z int // z`},
+			[]ast.Node{astStmt, astDecl}},
+		{"type T int\n\nfunc (T) M() {}",
+			[]string{`type T int`, `func (T) M() {}`},
+			[]ast.Node{astDecl, &ast.FuncDecl{}}},
+		{`{ a := 1; b := 2 }`,
+			[]string{`{ a := 1; b := 2 }`},
+			[]ast.Node{astBlock}},
+		{"package p\n\nvar x = 1",
+			[]string{"package p\n\nvar x = 1"},
+			[]ast.Node{astFile}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.exp)
+
+		l, err := SourceList(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceList; got %v`, err)
+		}
+		if exp, got := len(test.exp), l.Len(); exp != got {
+			t.Fatalf(`exp %v nodes; got %v`, exp, got)
+		}
+		for i := range test.exp {
+			expTyp, gotTyp := reflect.TypeOf(test.types[i]), reflect.TypeOf(l.Nodes[i])
+			if expTyp != gotTyp {
+				t.Fatalf(`exp node #%v to be %v; got %v`, i, expTyp, gotTyp)
+			}
+			if exp, got := test.exp[i], l.Sprint(i); exp != got {
+				t.Fatalf(`exp node #%v to print %q; got %q`, i, exp, got)
+			}
+			if i > 0 && l.Nodes[i-1].Pos() >= l.Nodes[i].Pos() {
+				t.Fatalf(`exp node #%v to follow node #%v`, i, i-1)
+			}
+		}
+	}
+
+	t.Run(`Error`, func(t *testing.T) {
+		l, err := SourceList(`{`)
+		if err == nil {
+			t.Fatal(`exp non-nil err from SourceList`)
+		}
+		if l != nil {
+			t.Fatalf(`exp nil list on error; got %v`, l)
+		}
+	})
+}
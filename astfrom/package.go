@@ -0,0 +1,508 @@
+package astfrom
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SourcePackage parses each of the given sources, keyed by file name, into a
+// file of a single *ast.Package. Sources without a package clause are treated
+// as a list of top-level declarations and given the package name declared by
+// the other files, or the package name used for scaffolding if none declare
+// one.
+//
+// The returned package is never nil, nor is the returned file set holding the
+// positions of each of its files. The package clause given to a source without
+// one is followed by a line directive, so its positions still resolve to the
+// lines and columns of that source. A source which fails to parse is added to
+// the package as a file whose name is an *ast.Ident containing the error, and
+// the errors of all such files are joined into the returned error.
+func SourcePackage(srcs map[string]string) (*ast.Package, *token.FileSet, error) {
+	names := make([]string, 0, len(srcs))
+	for name := range srcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		errs    []error
+		pending []string
+		fset    = token.NewFileSet()
+		pkg     = &ast.Package{Files: make(map[string]*ast.File, len(srcs))}
+	)
+	add := func(name string, file *ast.File, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", name, err))
+			file = &ast.File{Name: errIdent(err)}
+		}
+		pkg.Files[name] = file
+	}
+
+	for _, name := range names {
+		file, err := parseFile(fset, name, srcs[name], 0)
+		switch {
+		case err == nil:
+			if pkg.Name == `` {
+				pkg.Name = file.Name.Name
+			}
+			if file.Name.Name != pkg.Name {
+				err = fmt.Errorf("found package %v, expected %v", file.Name.Name, pkg.Name)
+			}
+			add(name, file, err)
+		case hasPackageClause(srcs[name]):
+			add(name, nil, err)
+		default:
+			pending = append(pending, name)
+		}
+	}
+	if pkg.Name == `` {
+		pkg.Name = pkgSentinel
+	}
+
+	for _, name := range pending {
+		src := "package " + pkg.Name + "\n\n/*line " + name + ":1:1*/" + srcs[name]
+		file, err := parseFile(fset, name, src, 0)
+		add(name, file, err)
+	}
+	return pkg, fset, errors.Join(errs...)
+}
+
+// parseFile calls parser.ParseFile within recoverFn.
+func parseFile(fset *token.FileSet, name, src string, mode parser.Mode) (*ast.File, error) {
+	var file *ast.File
+	err := recoverFn(func() (err error) {
+		file, err = parser.ParseFile(fset, name, src, mode)
+		return err
+	})
+	return file, err
+}
+
+// hasPackageClause reports whether src begins with a package clause.
+func hasPackageClause(src string) bool {
+	_, err := parseFile(token.NewFileSet(), ``, src, parser.PackageClauseOnly)
+	return err == nil
+}
+
+// IsPackage reports whether src is a complete Go source file which may form a
+// package, parsing as written without the need for any scaffolding and without
+// declaration errors such as a name declared twice. Every src for which
+// IsPackage reports true is also a file.
+func IsPackage(src string) bool {
+	_, err := parseFile(token.NewFileSet(), `string.go`, src, parser.DeclarationErrors)
+	return err == nil
+}
+
+// IsFile reports whether src is a complete Go source file, a package clause
+// followed by top-level declarations, parsing as written without the need for
+// any scaffolding. Fragments which Source must expand before parsing, such as
+// a list of declarations without a package clause or `x := 1`, report false,
+// as does source holding nothing but whitespace or comments.
+func IsFile(src string) bool {
+	_, err := parseFile(token.NewFileSet(), `string.go`, src, 0)
+	return err == nil
+}
+
+// PackageName returns the package name declared by src, which must be a
+// complete file beginning with a package clause.
+func PackageName(src string) (string, error) {
+	file, err := parseFile(token.NewFileSet(), `string.go`, src, 0)
+	if err != nil {
+		return ``, err
+	}
+	return file.Name.Name, nil
+}
+
+// Header parses only the package clause and import declarations of src, which
+// must be a complete file beginning with a package clause. Parsing stops after
+// the last import declaration, so the returned *ast.File has no other Decls
+// and source following the imports is neither parsed nor validated. This makes
+// Header much cheaper than a full parse when scanning many files for their
+// package names and imports. Comments are parsed, so the package doc comment
+// of src is available through PackageDoc.
+func Header(src string) (*ast.File, error) {
+	return parseFile(token.NewFileSet(), `string.go`, src, parser.ImportsOnly|parser.ParseComments)
+}
+
+// PackageDoc returns the package doc comment of node, the comment group
+// immediately preceding the package clause of an *ast.File, and whether one
+// was found. For an *ast.Package the doc comment of the first file by name
+// which has one is returned. Doc comments are only recorded when parsing with
+// parser.ParseComments, such as by Header or SourceWith given that Mode, while
+// sentinel files created by the scaffolding never have one.
+func PackageDoc(node ast.Node) (*ast.CommentGroup, bool) {
+	switch T := node.(type) {
+	case *ast.File:
+		if T != nil && T.Doc != nil && T.Name.Name != pkgSentinel {
+			return T.Doc, true
+		}
+	case *ast.Package:
+		if T == nil {
+			break
+		}
+		names := make([]string, 0, len(T.Files))
+		for name := range T.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if doc, ok := PackageDoc(T.Files[name]); ok {
+				return doc, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Imports returns all import specs declared by src in source order, from both
+// grouped and single import declarations. The src may be a complete file or
+// a list of top-level declarations without a package clause. Each spec
+// contains the quoted Path and, for named, dot or blank imports, the Name.
+func Imports(src string) ([]*ast.ImportSpec, error) {
+	file, err := sourceFile(token.NewFileSet(), src, 0)
+	if err != nil {
+		return nil, err
+	}
+	return file.Imports, nil
+}
+
+// Funcs returns all top-level function and method declarations of src in
+// source order. The src may be a complete file or a list of top-level
+// declarations without a package clause.
+func Funcs(src string) ([]*ast.FuncDecl, error) {
+	file, err := sourceFile(token.NewFileSet(), src, 0)
+	if err != nil {
+		return nil, err
+	}
+	fns := make([]*ast.FuncDecl, 0, len(file.Decls))
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			fns = append(fns, fn)
+		}
+	}
+	return fns, nil
+}
+
+// Types returns all top-level type specs of src in source order, from both
+// grouped and single type declarations. The src may be a complete file or a
+// list of top-level declarations without a package clause. Each spec contains
+// the Name, any TypeParams of a generic type and the Type expression, with a
+// valid Assign position for an alias such as `type A = B`. Types declared
+// within function bodies are not returned.
+func Types(src string) ([]*ast.TypeSpec, error) {
+	file, err := sourceFile(token.NewFileSet(), src, 0)
+	if err != nil {
+		return nil, err
+	}
+	var specs []*ast.TypeSpec
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			specs = append(specs, spec.(*ast.TypeSpec))
+		}
+	}
+	if specs == nil {
+		specs = []*ast.TypeSpec{}
+	}
+	return specs, nil
+}
+
+// Directive is a directive comment such as `//go:embed pattern`, found within
+// source by Directives.
+type Directive struct {
+	// Name is the tool and name of the directive separated by a colon, such
+	// as "go:embed".
+	Name string
+
+	// Args is the text following the name, without leading or trailing
+	// whitespace, such as "pattern".
+	Args string
+
+	// Pos is the position of the leading "//" of the directive within the
+	// source given to Directives, with an empty Filename.
+	Pos token.Position
+}
+
+// Directives returns each directive comment of src in source order, such as
+// `//go:build linux`, `//go:noinline` or `//go:generate stringer -type T`. A
+// directive is a line comment of the form `//tool:name args` with no space
+// following the slashes, as recognized by the go command. The src may be a
+// complete file or a list of top-level declarations without a package clause.
+func Directives(src string) ([]Directive, error) {
+	fset := token.NewFileSet()
+	file, err := parseFile(fset, `string.go`, src, parser.ParseComments)
+	shift := 0
+	if err != nil && !hasPackageClause(src) {
+		expanded := expand(src, KindFile, KindPkg)
+		shift = len(expanded) - len(src)
+		fset = token.NewFileSet()
+		file, err = parseFile(fset, `string.go`, expanded, parser.ParseComments)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := []Directive{}
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			name, args, ok := parseDirective(c.Text)
+			if !ok {
+				continue
+			}
+			off := fset.Position(c.Slash).Offset - shift
+			line := strings.Count(src[:off], "\n") + 1
+			col := off - strings.LastIndexByte(src[:off], '\n')
+			dirs = append(dirs, Directive{
+				Name: name,
+				Args: args,
+				Pos:  token.Position{Offset: off, Line: line, Column: col},
+			})
+		}
+	}
+	return dirs, nil
+}
+
+// parseDirective splits text, the text of a comment, into the name and args of
+// a directive of the form `//tool:name args`, reporting false when it isn't
+// one. The tool and the first byte of the name must be lowercase ASCII letters
+// or digits.
+func parseDirective(text string) (name, args string, ok bool) {
+	if !strings.HasPrefix(text, `//`) {
+		return ``, ``, false
+	}
+	text = text[len(`//`):]
+	colon := strings.IndexByte(text, ':')
+	if colon <= 0 || colon+1 >= len(text) {
+		return ``, ``, false
+	}
+	for idx := 0; idx <= colon+1; idx++ {
+		if b := text[idx]; idx != colon && !('a' <= b && b <= 'z' || '0' <= b && b <= '9') {
+			return ``, ``, false
+		}
+	}
+	name = text
+	if idx := strings.IndexFunc(text, unicode.IsSpace); idx >= 0 {
+		name, args = text[:idx], strings.TrimSpace(text[idx:])
+	}
+	return name, args, true
+}
+
+// sourceFile parses src as a complete file, adding the sentinel package clause
+// when src is a list of top-level declarations without one.
+func sourceFile(fset *token.FileSet, src string, mode parser.Mode) (*ast.File, error) {
+	file, err := parseFile(fset, `string.go`, src, mode)
+	if err != nil && !hasPackageClause(src) {
+		return parseFile(fset, `string.go`, expand(src, KindFile, KindPkg), mode)
+	}
+	return file, err
+}
+
+// CanonicalizeImports merges all of the import declarations of file, which was
+// parsed using fset, into a single declaration in place of the first. Imports
+// of the standard library are grouped ahead of all others as goimports groups
+// them, each group sorted by path and then name with a blank line between the
+// two, and any duplicate imports of the same path and name are removed.
+//
+// The doc and line comments of each kept spec move with it, as does the doc
+// comment of a merged declaration without parentheses, and comments of removed
+// specs are removed from file. Other comments within the merged declarations
+// are kept at the top of the merged declaration. The merged declaration is
+// positioned within a file added to fset laid out as gofmt prints it, so file
+// prints canonically without the blank lines of the declarations it replaced.
+func CanonicalizeImports(fset *token.FileSet, file *ast.File) {
+	var (
+		first = -1
+		gens  []*ast.GenDecl
+		decls []ast.Decl
+		specs []*ast.ImportSpec
+		drop  = make(map[*ast.CommentGroup]bool)
+		seen  = make(map[string]bool)
+	)
+	for idx, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			decls = append(decls, decl)
+			continue
+		}
+		if first < 0 {
+			first = idx
+		}
+		gens = append(gens, gen)
+		for i, spec := range gen.Specs {
+			is := spec.(*ast.ImportSpec)
+			if i == 0 && !gen.Lparen.IsValid() && is.Doc == nil {
+				is.Doc = gen.Doc
+			}
+			drop[is.Doc], drop[is.Comment] = true, true
+			key := importKey(is)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			specs = append(specs, is)
+		}
+	}
+	if first < 0 {
+		return
+	}
+
+	sort.SliceStable(specs, func(i, j int) bool {
+		if si, sj := importStd(specs[i]), importStd(specs[j]); si != sj {
+			return si
+		}
+		if pi, pj := importPath(specs[i]), importPath(specs[j]); pi != pj {
+			return pi < pj
+		}
+		return importName(specs[i]) < importName(specs[j])
+	})
+
+	head, tail := gens[0], gens[len(gens)-1]
+	merged := &ast.GenDecl{
+		TokPos: head.TokPos,
+		Tok:    token.IMPORT,
+		Specs:  make([]ast.Spec, len(specs)),
+	}
+	if head.Lparen.IsValid() {
+		merged.Doc = head.Doc
+	}
+	for idx, spec := range specs {
+		merged.Specs[idx] = spec
+	}
+
+	// A sole import is declared without parentheses, unless it has a doc
+	// comment other than that of the declaration it was declared by.
+	if len(specs) == 1 && merged.Doc == nil && specs[0].Doc == head.Doc {
+		merged.Doc, specs[0].Doc = specs[0].Doc, nil
+	}
+	var before, floating, after []*ast.CommentGroup
+	for _, cg := range file.Comments {
+		switch {
+		case cg == merged.Doc:
+			before = append(before, cg)
+		case drop[cg]:
+		case cg.Pos() < head.TokPos:
+			before = append(before, cg)
+		case cg.End() <= tail.End():
+			floating = append(floating, cg)
+		default:
+			after = append(after, cg)
+		}
+	}
+	parens := len(specs) > 1 || specs[0].Doc != nil || len(floating) > 0
+
+	// The merged declaration is laid out one line at a time from the end of
+	// its import keyword, each line a byte long, so the printer finds every
+	// comment and spec on the line gofmt would print it on. The layout is no
+	// longer than the declarations it replaces, so the comments which follow
+	// them are still printed after it.
+	var (
+		name  string
+		off   int
+		lines = []int{0}
+	)
+	if tf := fset.File(head.TokPos); tf != nil {
+		name, off = tf.Name(), tf.Offset(head.TokPos)+len(token.IMPORT.String())
+	}
+	line := func() int {
+		off++
+		lines = append(lines, off)
+		return off
+	}
+	var laid []*ast.CommentGroup
+	layout := func(cg *ast.CommentGroup, at func() int) {
+		if cg == nil {
+			return
+		}
+		for _, c := range cg.List {
+			c.Slash = token.Pos(at())
+		}
+		laid = append(laid, cg)
+	}
+
+	lparen := off
+	for _, cg := range floating {
+		layout(cg, line)
+	}
+	if len(floating) > 0 {
+		line()
+	}
+	for idx, spec := range specs {
+		if idx > 0 && importStd(specs[idx-1]) != importStd(spec) {
+			line()
+		}
+		layout(spec.Doc, line)
+		pos := off
+		if parens {
+			pos = line()
+		}
+		if spec.Name != nil {
+			spec.Name.NamePos = token.Pos(pos)
+		}
+		spec.Path.ValuePos, spec.EndPos = token.Pos(pos), token.Pos(pos)
+		layout(spec.Comment, func() int { return pos })
+	}
+	rparen := line()
+
+	// Positions are offsets above, made relative to the added file.
+	tf := fset.AddFile(name, -1, off+1)
+	tf.SetLines(lines)
+	base := token.Pos(tf.Base())
+	for _, cg := range laid {
+		for _, c := range cg.List {
+			c.Slash += base
+		}
+	}
+	for _, spec := range specs {
+		if spec.Name != nil {
+			spec.Name.NamePos += base
+		}
+		spec.Path.ValuePos += base
+		spec.EndPos += base
+	}
+	if parens {
+		merged.Lparen, merged.Rparen = base+token.Pos(lparen), base+token.Pos(rparen)
+	}
+	file.Comments = append(append(before, laid...), after...)
+
+	decls = append(decls[:first], append([]ast.Decl{merged}, decls[first:]...)...)
+	file.Decls = decls
+	file.Imports = specs
+}
+
+// importStd reports whether spec imports a package of the standard library,
+// which goimports takes to be any path without a dot in its first element.
+func importStd(spec *ast.ImportSpec) bool {
+	path := importPath(spec)
+	if idx := strings.IndexByte(path, '/'); idx >= 0 {
+		path = path[:idx]
+	}
+	return !strings.Contains(path, `.`)
+}
+
+func importPath(spec *ast.ImportSpec) string {
+	path, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return spec.Path.Value
+	}
+	return path
+}
+
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name == nil {
+		return ``
+	}
+	return spec.Name.Name
+}
+
+func importKey(spec *ast.ImportSpec) string {
+	return importName(spec) + ` ` + importPath(spec)
+}
@@ -0,0 +1,586 @@
+package astfrom
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestSourcePackage(t *testing.T) {
+	t.Run(`Files`, func(t *testing.T) {
+		pkg, _, err := SourcePackage(map[string]string{
+			`a.go`: "package foo\n\ntype T struct{}",
+			`b.go`: `func (T) Method() {}`,
+			`c.go`: "var v = T{}\nconst c = 1",
+		})
+		if err != nil {
+			t.Fatalf(`exp nil err from SourcePackage; got %v`, err)
+		}
+		if exp, got := `foo`, pkg.Name; exp != got {
+			t.Fatalf(`exp package name %v; got %v`, exp, got)
+		}
+		if exp, got := 3, len(pkg.Files); exp != got {
+			t.Fatalf(`exp %v files; got %v`, exp, got)
+		}
+
+		exp := map[string]int{`a.go`: 1, `b.go`: 1, `c.go`: 2}
+		for name, file := range pkg.Files {
+			if exp, got := `foo`, file.Name.Name; exp != got {
+				t.Fatalf(`exp file %v package name %v; got %v`, name, exp, got)
+			}
+			if exp, got := exp[name], len(file.Decls); exp != got {
+				t.Fatalf(`exp file %v to have %v decls; got %v`, name, exp, got)
+			}
+		}
+		if _, ok := pkg.Files[`b.go`].Decls[0].(*ast.FuncDecl); !ok {
+			t.Fatalf(`exp *ast.FuncDecl; got %T`, pkg.Files[`b.go`].Decls[0])
+		}
+	})
+	t.Run(`Positions`, func(t *testing.T) {
+		pkg, fset, err := SourcePackage(map[string]string{
+			`a.go`: "package foo\n\ntype T struct{}",
+			`b.go`: "var v = T{}\n\n\tconst c = 1",
+		})
+		if err != nil {
+			t.Fatalf(`exp nil err from SourcePackage; got %v`, err)
+		}
+		type test struct {
+			node ast.Node
+			exp  string
+		}
+		tests := []test{
+			{pkg.Files[`a.go`].Decls[0], `a.go:3:1`},
+			{pkg.Files[`b.go`].Decls[0], `b.go:1:1`},
+			{pkg.Files[`b.go`].Decls[1], `b.go:3:2`},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - exp %T at %v`, idx, test.node, test.exp)
+			if exp, got := test.exp, fset.Position(test.node.Pos()).String(); exp != got {
+				t.Fatalf(`exp position %v; got %v`, exp, got)
+			}
+		}
+	})
+	t.Run(`Sentinel`, func(t *testing.T) {
+		pkg, _, err := SourcePackage(map[string]string{`a.go`: `type T int`})
+		if err != nil {
+			t.Fatalf(`exp nil err from SourcePackage; got %v`, err)
+		}
+		if exp, got := pkgSentinel, pkg.Name; exp != got {
+			t.Fatalf(`exp package name %v; got %v`, exp, got)
+		}
+	})
+	t.Run(`Malformed`, func(t *testing.T) {
+		pkg, _, err := SourcePackage(map[string]string{
+			`a.go`: "package foo\n\ntype T struct{}",
+			`b.go`: `func (T) Method() {`,
+			`c.go`: "package foo\n\nfunc {",
+			`d.go`: `package bar`,
+		})
+		if err == nil {
+			t.Fatal(`exp non-nil err from SourcePackage`)
+		}
+		if exp, got := 4, len(pkg.Files); exp != got {
+			t.Fatalf(`exp %v files; got %v`, exp, got)
+		}
+		if exp, got := 1, len(pkg.Files[`a.go`].Decls); exp != got {
+			t.Fatalf(`exp %v decls in valid file; got %v`, exp, got)
+		}
+		for _, name := range []string{`b.go`, `c.go`, `d.go`} {
+			file := pkg.Files[name]
+			if file == nil || file.Name == nil || len(file.Decls) != 0 {
+				t.Fatalf(`exp file %v to be an error placeholder; got %#v`, name, file)
+			}
+			if file.Name.Name == `foo` {
+				t.Fatalf(`exp file %v name to contain an error`, name)
+			}
+		}
+	})
+}
+
+func TestIsFile(t *testing.T) {
+	type test struct {
+		src  string
+		file bool
+		pkg  bool
+	}
+	tests := []test{
+		{`package main`, true, true},
+		{"package main\n\nfunc main() {}\n", true, true},
+		{"// Package doc.\npackage foo\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint", true, true},
+		{"package p\n\nvar x int\nvar x int", true, false},
+		{"package p\n\nfunc f() { goto L }", true, false},
+		{`func f() {}`, false, false},
+		{"import \"fmt\"\n\nvar x = fmt.Sprint()", false, false},
+		{"type T int\n\nfunc (T) M() {}", false, false},
+		{`var x = 1`, false, false},
+		{``, false, false},
+		{" \n\t\n", false, false},
+		{`// x`, false, false},
+		{"/* x */\n\n// y\n", false, false},
+		{`foo`, false, false},
+		{`x := 1`, false, false},
+		{`if x { y() }`, false, false},
+		{`{ f() }`, false, false},
+		{`package main; func {`, false, false},
+		{"func f() {}\npackage main", false, false},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp file %v pkg %v`,
+			idx, test.src, test.file, test.pkg)
+
+		if exp, got := test.file, IsFile(test.src); exp != got {
+			t.Fatalf(`exp IsFile to return %v; got %v`, exp, got)
+		}
+		if exp, got := test.pkg, IsPackage(test.src); exp != got {
+			t.Fatalf(`exp IsPackage to return %v; got %v`, exp, got)
+		}
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	type test struct {
+		src string
+		exp string
+	}
+	tests := []test{
+		{`package main`, `main`},
+		{`package foo;`, `foo`},
+		{"// Package bar does things.\npackage bar\n\nimport \"fmt\"\n\nfunc F() { fmt.Println() }", `bar`},
+		{"\n\n\tpackage baz\n\nvar x = 1\n\n", `baz`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		got, err := PackageName(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from PackageName; got %v`, err)
+		}
+		if exp := test.exp; exp != got {
+			t.Fatalf(`exp package name %q; got %q`, exp, got)
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{``, `foo`, `x := 1`, `func f() {}`, `package main; func {`} {
+			got, err := PackageName(src)
+			if err == nil {
+				t.Fatalf(`exp non-nil err from PackageName(%q); got %q`, src, got)
+			}
+			if got != `` {
+				t.Fatalf(`exp empty package name on error; got %q`, got)
+			}
+		}
+	})
+}
+
+func TestHeader(t *testing.T) {
+	type test struct {
+		src     string
+		name    string
+		imports []string
+	}
+	tests := []test{
+		{`package main`, `main`, []string{}},
+		{"package foo\n\nimport \"fmt\"", `foo`, []string{`"fmt"`}},
+		{"package foo\n\nimport (\n\t\"fmt\"\n\tx \"os\"\n)\n\nimport \"io\"\n\nfunc F() {}",
+			`foo`, []string{`"fmt"`, `"os"`, `"io"`}},
+		{"// Package bar.\npackage bar\n\nimport \"fmt\"\n\nfunc {{ not parsed", `bar`, []string{`"fmt"`}},
+		{"package baz\n\nvar x = 1\n\nimport \"fmt\"", `baz`, []string{}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v %v`, idx, test.src, test.name, test.imports)
+
+		file, err := Header(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from Header; got %v`, err)
+		}
+		if exp, got := test.name, file.Name.Name; exp != got {
+			t.Fatalf(`exp package name %q; got %q`, exp, got)
+		}
+		if exp, got := len(test.imports), len(file.Imports); exp != got {
+			t.Fatalf(`exp %v imports; got %v`, exp, got)
+		}
+		for i, spec := range file.Imports {
+			if exp, got := test.imports[i], spec.Path.Value; exp != got {
+				t.Fatalf(`exp import #%v path %v; got %v`, i, exp, got)
+			}
+		}
+		for _, decl := range file.Decls {
+			if gd, ok := decl.(*ast.GenDecl); !ok || gd.Tok != token.IMPORT {
+				t.Fatalf(`exp only import decls; got %T`, decl)
+			}
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{``, `import "fmt"`, `func f() {}`, `package`, "package p\n\nimport \"fmt"} {
+			if file, err := Header(src); err == nil {
+				t.Fatalf(`exp non-nil err from Header(%q); got %v`, src, file)
+			}
+		}
+	})
+}
+
+func TestPackageDoc(t *testing.T) {
+	type test struct {
+		src string
+		exp string
+	}
+	tests := []test{
+		{`package main`, ``},
+		{"// Package foo does things.\npackage foo", "Package foo does things.\n"},
+		{"// Package foo does things.\n//\n// More.\npackage foo\n\nfunc F() {}", "Package foo does things.\n\nMore.\n"},
+		{"/*\nPackage foo.\n*/\npackage foo", "Package foo.\n"},
+		{"// Detached.\n\npackage foo", ``},
+		{"//go:build linux\n\n// Package foo.\npackage foo", "Package foo.\n"},
+		{"package foo\n\n// F doc.\nfunc F() {}", ``},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.exp)
+
+		file, err := Header(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from Header; got %v`, err)
+		}
+		doc, ok := PackageDoc(file)
+		if exp, got := test.exp != ``, ok; exp != got {
+			t.Fatalf(`exp ok %v; got %v`, exp, got)
+		}
+		if exp, got := test.exp, doc.Text(); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+
+		node := SourceWith(test.src, Options{Mode: parser.ParseComments})
+		if got, _ := PackageDoc(node); got.Text() != test.exp {
+			t.Fatalf(`exp SourceWith node to have doc %q; got %q`, test.exp, got.Text())
+		}
+		if got, ok := PackageDoc(Source(test.src)); ok {
+			t.Fatalf(`exp no doc without ParseComments; got %q`, got.Text())
+		}
+	}
+
+	t.Run(`Package`, func(t *testing.T) {
+		fset := token.NewFileSet()
+		pkg := &ast.Package{Name: `foo`, Files: make(map[string]*ast.File)}
+		for name, src := range map[string]string{
+			`a.go`: "package foo",
+			`b.go`: "// Package foo from b.\npackage foo",
+			`c.go`: "// Package foo from c.\npackage foo",
+		} {
+			f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+			if err != nil {
+				t.Fatalf(`exp nil err from ParseFile; got %v`, err)
+			}
+			pkg.Files[name] = f
+		}
+		doc, ok := PackageDoc(pkg)
+		if !ok || doc.Text() != "Package foo from b.\n" {
+			t.Fatalf(`exp doc of b.go; got %q`, doc.Text())
+		}
+	})
+	t.Run(`Others`, func(t *testing.T) {
+		for _, node := range []ast.Node{
+			nil, (*ast.File)(nil), (*ast.Package)(nil),
+			SourceWith("// F doc.\nfunc F() {}", Options{Mode: parser.ParseComments}),
+			SourceWith("// x doc.\nvar x = 1", Options{Mode: parser.ParseComments, Aggressive: true}),
+		} {
+			if doc, ok := PackageDoc(node); ok || doc != nil {
+				t.Fatalf(`exp no doc for %T; got %q`, node, doc.Text())
+			}
+		}
+	})
+}
+
+func TestImports(t *testing.T) {
+	type spec struct {
+		name, path string
+	}
+	type test struct {
+		src string
+		exp []spec
+	}
+	tests := []test{
+		{`package main`, nil},
+		{`import "fmt"`, []spec{{``, `"fmt"`}}},
+		{"package main\n\nimport \"fmt\"\nimport \"os\"",
+			[]spec{{``, `"fmt"`}, {``, `"os"`}}},
+		{"package main\n\nimport (\n\t\"fmt\"\n\tstdio \"io\"\n\t. \"strings\"\n\t_ \"embed\"\n)\n\nimport \"C\"",
+			[]spec{{``, `"fmt"`}, {`stdio`, `"io"`}, {`.`, `"strings"`}, {`_`, `"embed"`}, {``, `"C"`}}},
+		{"import (\n\t\"net/http\"\n)\n\nfunc F() { http.Get(``) }",
+			[]spec{{``, `"net/http"`}}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		specs, err := Imports(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from Imports; got %v`, err)
+		}
+		if exp, got := len(test.exp), len(specs); exp != got {
+			t.Fatalf(`exp %v specs; got %v`, exp, got)
+		}
+		for i, exp := range test.exp {
+			var name string
+			if specs[i].Name != nil {
+				name = specs[i].Name.Name
+			}
+			if exp.name != name || exp.path != specs[i].Path.Value {
+				t.Fatalf(`exp spec #%v %v; got {%v %v}`, i, exp, name, specs[i].Path.Value)
+			}
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{`import fmt`, `package main; import (`, `{`} {
+			if _, err := Imports(src); err == nil {
+				t.Fatalf(`exp non-nil err from Imports(%q)`, src)
+			}
+		}
+	})
+}
+
+func TestFuncs(t *testing.T) {
+	type test struct {
+		src string
+		exp []string
+	}
+	tests := []test{
+		{`package main`, []string{}},
+		{`var x = 1`, []string{}},
+		{`func f() {}`, []string{`f`}},
+		{"package main\n\nfunc main() {}\n\nfunc helper(x int) int { return x }",
+			[]string{`main`, `helper`}},
+		{"type T struct{}\n\nfunc (T) A() {}\n\nfunc New() *T { return nil }\n\nfunc (t *T) B() {}",
+			[]string{`(T) A`, `New`, `(*T) B`}},
+		{"func init() {}\nvar x = func() {}\nfunc init() {}", []string{`init`, `init`}},
+		{"func G[T any](v T) T { return v }", []string{`G`}},
+		{"func " + fnSentinel + " {}", []string{fnSentinelName}},
+		{"package main\n\nfunc " + fnSentinel + " {}", []string{fnSentinelName}},
+		{"type T int\n\nfunc (T) " + fnSentinel + " {}", []string{`(T) ` + fnSentinelName}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		fns, err := Funcs(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from Funcs; got %v`, err)
+		}
+		if exp, got := len(test.exp), len(fns); exp != got {
+			t.Fatalf(`exp %v funcs; got %v`, exp, got)
+		}
+		for i, fn := range fns {
+			got := fn.Name.Name
+			if fn.Recv != nil {
+				got = `(` + sprint(t, fn.Recv.List[0].Type) + `) ` + got
+			}
+			if exp := test.exp[i]; exp != got {
+				t.Fatalf(`exp func #%v to be %v; got %v`, i, exp, got)
+			}
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{`x := 1`, `func {`, `f()`} {
+			if fns, err := Funcs(src); err == nil {
+				t.Fatalf(`exp non-nil err from Funcs(%q); got %v`, src, fns)
+			}
+		}
+	})
+}
+
+func TestTypes(t *testing.T) {
+	type test struct {
+		src string
+		exp []string
+	}
+	tests := []test{
+		{`package main`, []string{}},
+		{`var x = 1`, []string{}},
+		{`type T int`, []string{`T int`}},
+		{"package main\n\ntype (\n\tA struct{ X int }\n\tB = A\n)\n\ntype C []B",
+			[]string{`A struct{ X int }`, `B = A`, `C []B`}},
+		{"type L[T any] []T\n\ntype M[K comparable, V any] map[K]V",
+			[]string{`L[T any] []T`, `M[K comparable, V any] map[K]V`}},
+		{"func f() {\n\ttype local int\n}\n\ntype I interface{ M() }",
+			[]string{`I interface{ M() }`}},
+		{"var x int\n\nfunc g() {}\n\ntype S string\n\nconst c = 1", []string{`S string`}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		specs, err := Types(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from Types; got %v`, err)
+		}
+		if exp, got := len(test.exp), len(specs); exp != got {
+			t.Fatalf(`exp %v types; got %v`, exp, got)
+		}
+		for i, spec := range specs {
+			got := spec.Name.Name
+			if spec.TypeParams != nil {
+				var params []string
+				for _, field := range spec.TypeParams.List {
+					var names []string
+					for _, name := range field.Names {
+						names = append(names, name.Name)
+					}
+					params = append(params, strings.Join(names, `, `)+` `+sprint(t, field.Type))
+				}
+				got += `[` + strings.Join(params, `, `) + `]`
+			}
+			if spec.Assign.IsValid() {
+				got += ` =`
+			}
+			got += ` ` + sprint(t, spec.Type)
+			if exp := test.exp[i]; exp != got {
+				t.Fatalf(`exp type #%v to be %v; got %v`, i, exp, got)
+			}
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{`x := 1`, `type {`, `type T`} {
+			if specs, err := Types(src); err == nil {
+				t.Fatalf(`exp non-nil err from Types(%q); got %v`, src, specs)
+			}
+		}
+	})
+}
+
+func TestDirectives(t *testing.T) {
+	type test struct {
+		src string
+		exp []Directive
+	}
+	pos := func(off, line, col int) token.Position {
+		return token.Position{Offset: off, Line: line, Column: col}
+	}
+	tests := []test{
+		{`package main`, []Directive{}},
+		{"// Comment.\n// go:notdirective\n/*go:block*/\nfunc f() {}", []Directive{}},
+		{"//go:build linux && amd64\n\npackage p",
+			[]Directive{{`go:build`, `linux && amd64`, pos(0, 1, 1)}}},
+		{"//go:noinline\nfunc f() {}",
+			[]Directive{{`go:noinline`, ``, pos(0, 1, 1)}}},
+		{"package p\n\nimport _ \"embed\"\n\n//go:embed a.txt  b.txt\nvar fs embed.FS",
+			[]Directive{{`go:embed`, `a.txt  b.txt`, pos(29, 5, 1)}}},
+		{"//go:generate stringer -type T\ntype T int\n\nfunc g() {\n\tx := 1 //lint:ignore U1000 unused\n}",
+			[]Directive{
+				{`go:generate`, `stringer -type T`, pos(0, 1, 1)},
+				{`lint:ignore`, `U1000 unused`, pos(62, 5, 9)},
+			}},
+		{"//Go:build x\n//go:\n//go: x\n//:x\n//go:x-y\targs \n\npackage p",
+			[]Directive{{`go:x-y`, `args`, pos(32, 5, 1)}}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		dirs, err := Directives(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from Directives; got %v`, err)
+		}
+		if exp, got := len(test.exp), len(dirs); exp != got {
+			t.Fatalf(`exp %v directives; got %v`, exp, got)
+		}
+		for i, dir := range dirs {
+			if exp, got := test.exp[i], dir; exp != got {
+				t.Fatalf("\n---- [exp] ----\n%+v\n\n---- [got] ----\n%+v\n", exp, got)
+			}
+			if !strings.HasPrefix(test.src[dir.Pos.Offset:], `//`+dir.Name) {
+				t.Fatalf(`exp directive #%v at offset %v`, i, dir.Pos.Offset)
+			}
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		for _, src := range []string{`x := 1`, `func {`, `f()`} {
+			if dirs, err := Directives(src); err == nil {
+				t.Fatalf(`exp non-nil err from Directives(%q); got %v`, src, dirs)
+			}
+		}
+	})
+}
+
+func TestCanonicalizeImports(t *testing.T) {
+	type test struct {
+		src string
+		exp string
+	}
+	canonicalize := func(t *testing.T, src string) (*ast.File, string) {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, `a.go`, src, parser.ParseComments)
+		if err != nil {
+			t.Fatalf(`exp nil err from ParseFile; got %v`, err)
+		}
+		CanonicalizeImports(fset, file)
+
+		var buf bytes.Buffer
+		if err := format.Node(&buf, fset, file); err != nil {
+			t.Fatalf(`exp nil err from format.Node; got %v`, err)
+		}
+		return file, buf.String()
+	}
+
+	tests := []test{
+		{"package p\n\nvar f int", "package p\n\nvar f int\n"},
+		{"package p\n\nimport \"os\"", "package p\n\nimport \"os\"\n"},
+		{"package p\n\nimport \"os\"\nimport \"os\"", "package p\n\nimport \"os\"\n"},
+		{"package p\n\nimport \"os\"\nimport (\"fmt\"; \"os\")\nimport \"fmt\"\n\nvar f int",
+			"package p\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nvar f int\n"},
+		{"package p\n\nimport (\n\t\"strings\"\n\tb \"bytes\"\n\t_ \"embed\"\n\t\"bytes\"\n\tb \"bytes\"\n)\n\nvar x int",
+			"package p\n\nimport (\n\t\"bytes\"\n\tb \"bytes\"\n\t_ \"embed\"\n\t\"strings\"\n)\n\nvar x int\n"},
+		{"package p\n\nimport \"z\"\nimport \"a\"\n\nvar x int",
+			"package p\n\nimport (\n\t\"a\"\n\t\"z\"\n)\n\nvar x int\n"},
+		{"package p\n\nimport (\n\t\"os\"\n\n\t\"fmt\"\n)\n\nimport \"bytes\"\n\nvar x int",
+			"package p\n\nimport (\n\t\"bytes\"\n\t\"fmt\"\n\t\"os\"\n)\n\nvar x int\n"},
+		{"package p\n\nimport \"github.com/a/b\"\nimport (\"os\"; \"example.com/c\"; \"net/http\")\n",
+			"package p\n\nimport (\n\t\"net/http\"\n\t\"os\"\n\n\t\"example.com/c\"\n\t\"github.com/a/b\"\n)\n"},
+		{"package p\n\nimport \"golang.org/x/tools\"\n", "package p\n\nimport \"golang.org/x/tools\"\n"},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.exp)
+
+		file, got := canonicalize(t, test.src)
+		if exp := test.exp; exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+
+		var specs int
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				specs += len(gen.Specs)
+			}
+		}
+		if exp, got := specs, len(file.Imports); exp != got {
+			t.Fatalf(`exp %v file imports; got %v`, exp, got)
+		}
+	}
+
+	t.Run(`Comments`, func(t *testing.T) {
+		tests := []test{
+			{"package p\n\nimport \"os\" // exit\n\nimport (\n\t// fmt doc\n\t\"fmt\"\n\t\"bytes\" // buf\n)\n\nvar x int\n",
+				"package p\n\nimport (\n\t\"bytes\" // buf\n\t// fmt doc\n\t\"fmt\"\n\t\"os\" // exit\n)\n\nvar x int\n"},
+			{"package p\n\n// Imports.\nimport \"z\" // z\n\n// a doc\nimport \"a\" // a\nimport \"z\" // dup\n\nvar x int\n",
+				"package p\n\nimport (\n\t// a doc\n\t\"a\" // a\n\t// Imports.\n\t\"z\" // z\n)\n\nvar x int\n"},
+			{"package p\n\nimport (\n\t\"os\" // os\n\t\"os\" // dup\n)\n",
+				"package p\n\nimport \"os\" // os\n"},
+			{"package p\n\n// Imports.\nimport \"os\"\n\n// main.\nfunc main() {}\n",
+				"package p\n\n// Imports.\nimport \"os\"\n\n// main.\nfunc main() {}\n"},
+			{"package p\n\n// Group.\nimport (\n\t// os doc\n\t\"os\"\n)\n",
+				"package p\n\n// Group.\nimport (\n\t// os doc\n\t\"os\"\n)\n"},
+			{"package p\n\nimport (\n\t\"os\"\n\n\t// Floating.\n\n\t\"example.com/a\" /* a */\n\t\"fmt\"\n)\n\n// X.\nvar x int\n",
+				"package p\n\nimport (\n\t// Floating.\n\n\t\"fmt\"\n\t\"os\"\n\n\t\"example.com/a\" /* a */\n)\n\n// X.\nvar x int\n"},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.exp)
+
+			if _, got := canonicalize(t, test.src); test.exp != got {
+				t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", test.exp, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+)
+
+// Option configures the Options used by Parse.
+type Option func(*Options)
+
+// WithOptions replaces the Options used by Parse with opts, so it's typically
+// given before any other Option.
+func WithOptions(opts Options) Option {
+	return func(o *Options) { *o = opts }
+}
+
+// WithMode sets Options.Mode, the mode passed to go/parser.
+func WithMode(mode parser.Mode) Option {
+	return func(o *Options) { o.Mode = mode }
+}
+
+// WithImports adds paths to Options.Imports, the packages in scope of src.
+func WithImports(paths ...string) Option {
+	return func(o *Options) { o.Imports = append(o.Imports, paths...) }
+}
+
+// WithPrefer sets Options.Prefer, the kind the climb begins at.
+func WithPrefer(kind Kind) Option {
+	return func(o *Options) { o.Prefer = kind }
+}
+
+// ParseResult holds everything known about a single parse of src, for tools
+// such as editors which need the node along with the context to relate it
+// back to src.
+type ParseResult struct {
+	// Node is the reduced node as returned from SourceWith, which is the node
+	// selected by Options.OnError when src failed to parse.
+	Node ast.Node
+
+	// Raw is the node before reduction as returned from SourceRaw, including
+	// the scaffolding src was expanded with. It's nil when src failed to
+	// parse.
+	Raw ast.Node
+
+	// Fset is the file set holding the positions of Node and Raw, which refer
+	// to the scaffolded source rather than src. When src failed to parse it
+	// holds a single file of src itself, so the positions of an *ast.BadExpr
+	// returned for Options.OnError resolve to the start and end of src.
+	Fset *token.FileSet
+
+	// Kind is the kind src was expanded to for the successful parse, or
+	// KindNode when src failed to parse.
+	Kind Kind
+
+	// OriginalPos maps a position of Node or Raw to the line and column it has
+	// within src, see Result.OriginalPos. It's never nil, returning the zero
+	// position for every pos when src failed to parse.
+	OriginalPos func(token.Pos) token.Position
+
+	// Errors holds each syntax error src failed to parse with, positioned
+	// within src, or the single error returned when it wasn't a syntax error.
+	// When every parse attempt failed these are the errors of the most
+	// specific attempt, the one parsing furthest into src, such as `expected
+	// operand` at 1:5 for `x :=`. It's empty when src parsed.
+	Errors []error
+
+	// Err is the error src failed to parse with as returned from SourceErr,
+	// or nil when src parsed.
+	Err error
+
+	// Attempts holds each parse attempt made while climbing towards a full
+	// package as returned from SourceTrace, the last being the one which
+	// produced Raw or the last failure.
+	Attempts []Attempt
+}
+
+// Parse parses src like SourceWith using the Options built by applying opts
+// to the zero Options in order, returning a ParseResult which is never nil.
+func Parse(src string, opts ...Option) *ParseResult {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var attempts []Attempt
+	res, err := o.result(src, func(a Attempt) {
+		attempts = append(attempts, a)
+	})
+	if err != nil {
+		fset := token.NewFileSet()
+		fset.AddFile(``, -1, len(src)).SetLinesForContent([]byte(src))
+		return &ParseResult{
+			Node: o.failed(src, err),
+			Fset: fset,
+			OriginalPos: func(token.Pos) token.Position {
+				return token.Position{}
+			},
+			Errors:   parseErrors(err),
+			Err:      err,
+			Attempts: attempts,
+		}
+	}
+	return &ParseResult{
+		Node:        res.Node,
+		Raw:         res.raw,
+		Fset:        res.Fset,
+		Kind:        res.Kind,
+		OriginalPos: res.OriginalPos,
+		Attempts:    attempts,
+	}
+}
+
+// parseErrors returns each entry of the syntax errors within err, see
+// ParseResult.Errors.
+func parseErrors(err error) []error {
+	if perr, ok := err.(*ParseError); ok {
+		err = perr.specific()
+	}
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) == 0 {
+		return []error{err}
+	}
+	errs := make([]error, len(list))
+	for idx, e := range list {
+		errs[idx] = e
+	}
+	return errs
+}
@@ -0,0 +1,211 @@
+package astfrom
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// SourcePartial behaves like SourceErr for well formed source. When src is
+// incomplete or malformed, such as the selector `foo.` while it's being typed,
+// it returns the best-effort partial node recovered by go/parser along with the
+// syntax error. Partial nodes may contain *ast.BadExpr, *ast.BadStmt or
+// *ast.BadDecl nodes as reported by HasErrors, or placeholder identifiers such
+// as the blank Sel of an incomplete selector.
+//
+// Input which is an incomplete expression is recovered at the expression level,
+// otherwise the statement level is used. If no partial node could be recovered
+// an *ast.Ident containing the error is returned as with SourceErr.
+func SourcePartial(src string) (ast.Node, error) {
+	fset := token.NewFileSet()
+	node, err := Options{}.parse(fset, src, nil)
+	if err == nil {
+		return node, nil
+	}
+	m, trimmed := Options{}.sourceMap(src)
+	if node, perr := partial(fset, m, trimmed); node != nil {
+		return node, perr
+	}
+	return errIdent(err), err
+}
+
+// partial returns the partial node recovered from src and the syntax error
+// which caused it to be partial, with its positions mapped by m.
+func partial(fset *token.FileSet, m *sourceMap, src string) (ast.Node, error) {
+	var expr ast.Expr
+	exprErr := recoverFn(func() (err error) {
+		expr, err = parser.ParseExprFrom(fset, `string.go`, src, 0)
+		return err
+	})
+	if expr != nil && errOffset(exprErr) >= len(src) {
+		return expr, m.mapErr(exprErr, Attempt{})
+	}
+
+	var file *ast.File
+	fileErr := recoverFn(func() (err error) {
+		cur := expand(src, KindDecl, KindPkg)
+		file, err = parser.ParseFile(fset, `string.go`, cur, 0)
+		return err
+	})
+	fileErr = m.mapErr(fileErr, Attempt{
+		offset: strings.Index(expand(scaffoldMark, KindDecl, KindPkg), scaffoldMark)})
+	if file == nil {
+		return nil, fileErr
+	}
+
+	var node ast.Node
+	if err := recoverFn(func() error {
+		node = reduce(file)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return node, fileErr
+}
+
+// IsIncomplete reports whether src fails to parse only because it ends early,
+// meaning more input could complete it. This is the case when src has unclosed
+// parentheses, brackets or braces, an unterminated raw string or general
+// comment, or ends with an operator awaiting its operand such as `x +`, `x :=`
+// or `foo.`. Source which parses, or which is malformed regardless of what may
+// follow it such as `x )`, is not incomplete.
+func IsIncomplete(src string) bool {
+	if !continues(src) {
+		return false
+	}
+	_, err := SourceErr(src)
+	return err != nil
+}
+
+// continues reports whether the tokens of src leave it open to continuation,
+// as described by IsIncomplete.
+func continues(src string) bool {
+	var (
+		s     scanner.Scanner
+		open  bool
+		depth int
+		last  token.Token
+	)
+	fset := token.NewFileSet()
+	file := fset.AddFile(``, fset.Base(), len(src))
+	s.Init(file, []byte(src), func(_ token.Position, msg string) {
+		switch msg {
+		case `raw string literal not terminated`, `comment not terminated`:
+			open = true
+		}
+	}, 0)
+	for {
+		_, tok, lit := s.Scan()
+		switch tok {
+		case token.EOF:
+			return open || depth > 0 || continuesAfter(last)
+		case token.LPAREN, token.LBRACK, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACK, token.RBRACE:
+			if depth--; depth < 0 {
+				return false
+			}
+		case token.SEMICOLON:
+			if lit == "\n" {
+				continue
+			}
+		}
+		last = tok
+	}
+}
+
+// continuesAfter reports whether tok, when last in the source, requires more
+// tokens to follow it.
+func continuesAfter(tok token.Token) bool {
+	switch tok {
+	case token.RPAREN, token.RBRACK, token.RBRACE, token.SEMICOLON,
+		token.INC, token.DEC, token.ELLIPSIS:
+		return false
+	}
+	return tok.IsOperator()
+}
+
+// HasErrors reports whether node contains an *ast.BadExpr, *ast.BadStmt or
+// *ast.BadDecl node, which go/parser creates in place of source it could not
+// parse. Nodes returned without an error from this package never contain them,
+// while those from SourcePartial often do.
+func HasErrors(node ast.Node) bool {
+	return badNode(node) != nil
+}
+
+// badNode returns the first Bad* node within node in depth-first order, or nil
+// if there are none.
+func badNode(node ast.Node) (bad ast.Node) {
+	if node == nil {
+		return nil
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.BadExpr, *ast.BadStmt, *ast.BadDecl:
+			bad = n
+		}
+		return bad == nil
+	})
+	return bad
+}
+
+// verifyNode returns an error if node contains a Bad* node or a `.(type)`
+// assertion outside of a type switch, which go/parser accepts anywhere,
+// reporting the position of the first within fset.
+func verifyNode(fset *token.FileSet, node ast.Node) error {
+	if bad := badNode(node); bad != nil {
+		return scanner.ErrorList{{
+			Pos: fset.Position(bad.Pos()), Msg: fmt.Sprintf("unexpected %T", bad)}}
+	}
+	if x := typeGuard(node); x != nil {
+		return scanner.ErrorList{{
+			Pos: fset.Position(x.Pos()),
+			Msg: fmt.Sprintf("use of %v.(type) outside type switch", types.ExprString(x.X))}}
+	}
+	return nil
+}
+
+// typeGuard returns the first `.(type)` assertion within node which isn't the
+// guard of a type switch, or nil if there are none.
+func typeGuard(node ast.Node) (guard *ast.TypeAssertExpr) {
+	if node == nil {
+		return nil
+	}
+	guards := make(map[*ast.TypeAssertExpr]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch T := n.(type) {
+		case *ast.TypeSwitchStmt:
+			switch A := T.Assign.(type) {
+			case *ast.ExprStmt:
+				if x, ok := A.X.(*ast.TypeAssertExpr); ok {
+					guards[x] = true
+				}
+			case *ast.AssignStmt:
+				if len(A.Rhs) == 1 {
+					if x, ok := A.Rhs[0].(*ast.TypeAssertExpr); ok {
+						guards[x] = true
+					}
+				}
+			}
+		case *ast.TypeAssertExpr:
+			if T.Type == nil && !guards[T] {
+				guard = T
+			}
+		}
+		return guard == nil
+	})
+	return guard
+}
+
+// errOffset returns the offset of the first error in err, or -1 if err does
+// not contain a position.
+func errOffset(err error) int {
+	if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+		return list[0].Pos.Offset
+	}
+	return -1
+}
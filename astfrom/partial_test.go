@@ -0,0 +1,168 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestSourcePartial(t *testing.T) {
+	type test struct {
+		src string
+		exp ast.Node
+		err string
+	}
+	tests := []test{
+		{`foo.bar`, &ast.SelectorExpr{}, ``},
+		{`x := 1`, astAssign, ``},
+		{`foo.`, &ast.SelectorExpr{}, `1:5: expected selector or type assertion, found 'EOF'`},
+		{`foo.bar.`, &ast.SelectorExpr{}, `1:9: expected selector or type assertion, found 'EOF'`},
+		{`foo(1, `, astCall, `1:7: expected ')', found 'EOF'`},
+		{`x := `, astAssign, `1:5: expected operand, found '}'`},
+		{`if x {`, &ast.IfStmt{}, `1:7: expected '}', found 'EOF'`},
+		{"\n\n  if x {", &ast.IfStmt{}, `3:9: expected '}', found 'EOF'`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %T`, idx, test.src, test.exp)
+
+		got, err := SourcePartial(test.src)
+		if err == nil && len(test.err) > 0 {
+			t.Fatalf(`exp err %q from SourcePartial; got nil`, test.err)
+		}
+		if err != nil && err.Error() != test.err {
+			t.Fatalf(`exp err %q from SourcePartial; got %q`, test.err, err)
+		}
+		expTyp, gotTyp := reflect.TypeOf(test.exp), reflect.TypeOf(got)
+		if expTyp != gotTyp {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", expTyp, gotTyp)
+		}
+	}
+
+	t.Run(`Selector`, func(t *testing.T) {
+		node, err := SourcePartial(`foo.`)
+		if err == nil {
+			t.Fatal(`exp non-nil err from SourcePartial`)
+		}
+		sel := node.(*ast.SelectorExpr)
+		if id, ok := sel.X.(*ast.Ident); !ok || id.Name != `foo` {
+			t.Fatalf(`exp receiver ident foo; got %#v`, sel.X)
+		}
+	})
+	t.Run(`Assign`, func(t *testing.T) {
+		node, _ := SourcePartial(`x := `)
+		if _, ok := node.(*ast.AssignStmt).Rhs[0].(*ast.BadExpr); !ok {
+			t.Fatalf(`exp *ast.BadExpr rhs; got %T`, node.(*ast.AssignStmt).Rhs[0])
+		}
+	})
+	t.Run(`Unrecoverable`, func(t *testing.T) {
+		node, err := SourcePartial(`}`)
+		if err == nil {
+			t.Fatal(`exp non-nil err from SourcePartial`)
+		}
+		if node == nil {
+			t.Fatal(`exp non-nil node from SourcePartial`)
+		}
+	})
+}
+
+func TestHasErrors(t *testing.T) {
+	type test struct {
+		src string
+		exp bool
+	}
+	tests := []test{
+		{`x := `, true},
+		{`a + `, true},
+		{`var = 1`, true},
+		{`x := 1; )`, true},
+		{`foo(`, false},
+		{`foo.`, false},
+		{`if x {`, false},
+		{`[]int{1,`, false},
+		{`a + b`, false},
+		{`func f() { x := 1 }`, false},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		node, _ := SourcePartial(test.src)
+		if exp, got := test.exp, HasErrors(node); exp != got {
+			t.Fatalf(`exp HasErrors to return %v; got %v`, exp, got)
+		}
+	}
+
+	t.Run(`Nodes`, func(t *testing.T) {
+		if HasErrors(nil) {
+			t.Fatal(`exp HasErrors(nil) to return false`)
+		}
+		for _, node := range []ast.Node{
+			&ast.BadExpr{},
+			&ast.BadStmt{},
+			&ast.BadDecl{},
+			&ast.ParenExpr{X: &ast.BadExpr{}},
+			&ast.BlockStmt{List: []ast.Stmt{&ast.EmptyStmt{}, &ast.BadStmt{}}},
+			&ast.File{Name: ast.NewIdent(`p`), Decls: []ast.Decl{&ast.BadDecl{}}},
+		} {
+			if !HasErrors(node) {
+				t.Fatalf(`exp HasErrors(%T) to return true`, node)
+			}
+		}
+	})
+
+	t.Run(`Verify`, func(t *testing.T) {
+		fset := token.NewFileSet()
+		if err := verifyNode(fset, Source(`a + b`)); err != nil {
+			t.Fatalf(`exp nil err from verifyNode; got %v`, err)
+		}
+		if err := verifyNode(fset, &ast.ExprStmt{X: &ast.BadExpr{}}); err == nil {
+			t.Fatal(`exp non-nil err from verifyNode`)
+		}
+	})
+}
+
+func TestIsIncomplete(t *testing.T) {
+	type test struct {
+		src string
+		exp bool
+	}
+	tests := []test{
+		{`foo(`, true},
+		{`foo(1, `, true},
+		{`[]int{1,`, true},
+		{`if x {`, true},
+		{"func f() {\n\tx := 1\n", true},
+		{`m[`, true},
+		{`x +`, true},
+		{`x :=`, true},
+		{`a &&`, true},
+		{`foo.`, true},
+		{`x = 1,`, true},
+		{"s := `raw", true},
+		{"/* comment", true},
+		{"x := 1 /* comment", true},
+
+		{``, false},
+		{`x`, false},
+		{`foo()`, false},
+		{`x := 1`, false},
+		{`x++`, false},
+		{"if x {\n}", false},
+		{"func f() {\n\tx := 1\n}", false},
+		{"s := `raw`", false},
+		{`// comment (`, false},
+		{`"string ("`, false},
+		{`x )`, false},
+		{`}`, false},
+		{`foo(}`, false},
+		{`x := 1 2`, false},
+		{`"unterminated`, false},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		if exp, got := test.exp, IsIncomplete(test.src); exp != got {
+			t.Fatalf(`exp IsIncomplete to return %v; got %v`, exp, got)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"sort"
+)
+
+// ReferencedPackages returns the sorted, unique names used as the qualifier of
+// a selector expression within node which aren't declared within node, such as
+// `fmt` and `http` from `fmt.Println(http.Get(u))`. Declarations are scoped
+// like Go, so a local variable or parameter shadowing a package name such as
+// `fmt := t; fmt.X()` isn't reported, while a use of the package before or
+// outside of the declaration is.
+//
+// Names are resolved from node alone, so an undeclared variable of a snippet
+// used as a qualifier, such as `x` in `x.Field`, is indistinguishable from a
+// package and reported. The result may be given to Options.Imports once each
+// name is mapped to its import path.
+func ReferencedPackages(node ast.Node) []string {
+	r := &refs{names: make(map[string]bool), pkgs: make(map[string]bool)}
+	if node != nil {
+		ast.Walk(r, node)
+	}
+
+	names := make([]string, 0, len(r.pkgs))
+	for name := range r.pkgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// refs is an ast.Visitor collecting the undeclared selector qualifiers within
+// the scope of its names.
+type refs struct {
+	parent *refs
+	names  map[string]bool
+	pkgs   map[string]bool
+}
+
+// scope returns a visitor for a new scope nested within r.
+func (r *refs) scope() *refs {
+	return &refs{parent: r, names: make(map[string]bool), pkgs: r.pkgs}
+}
+
+func (r *refs) declared(name string) bool {
+	for ; r != nil; r = r.parent {
+		if r.names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *refs) declare(exprs ...ast.Expr) {
+	for _, expr := range exprs {
+		if id, ok := expr.(*ast.Ident); ok && id.Name != `_` {
+			r.names[id.Name] = true
+		}
+	}
+}
+
+func (r *refs) declareFields(lists ...*ast.FieldList) {
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		for _, field := range list.List {
+			for _, name := range field.Names {
+				r.declare(name)
+			}
+		}
+	}
+}
+
+func (r *refs) walk(nodes ...ast.Node) {
+	for _, node := range nodes {
+		if v := reflect.ValueOf(node); v.IsValid() && !v.IsNil() {
+			ast.Walk(r, node)
+		}
+	}
+}
+
+// Visit implements ast.Visitor, walking the children of scoped nodes and
+// declarations itself so names are declared in the order Go makes them
+// visible.
+func (r *refs) Visit(node ast.Node) ast.Visitor {
+	switch T := node.(type) {
+	case *ast.SelectorExpr:
+		if id, ok := T.X.(*ast.Ident); ok && !r.declared(id.Name) {
+			r.pkgs[id.Name] = true
+		}
+	case *ast.File:
+		s := r.scope()
+		for _, decl := range T.Decls {
+			switch D := decl.(type) {
+			case *ast.FuncDecl:
+				if D.Recv == nil {
+					s.declare(D.Name)
+				}
+			case *ast.GenDecl:
+				for _, spec := range D.Specs {
+					switch S := spec.(type) {
+					case *ast.ValueSpec:
+						for _, name := range S.Names {
+							s.declare(name)
+						}
+					case *ast.TypeSpec:
+						s.declare(S.Name)
+					}
+				}
+			}
+		}
+		for _, decl := range T.Decls {
+			s.walk(decl)
+		}
+		return nil
+	case *ast.FuncDecl:
+		s := r.scope()
+		s.declareFields(T.Recv, T.Type.TypeParams, T.Type.Params, T.Type.Results)
+		s.walk(T.Recv, T.Type, T.Body)
+		return nil
+	case *ast.FuncLit:
+		s := r.scope()
+		s.declareFields(T.Type.TypeParams, T.Type.Params, T.Type.Results)
+		s.walk(T.Type, T.Body)
+		return nil
+	case *ast.TypeSpec:
+		r.declare(T.Name)
+		s := r.scope()
+		s.declareFields(T.TypeParams)
+		s.walk(T.TypeParams, T.Type)
+		return nil
+	case *ast.ValueSpec:
+		r.walk(T.Type)
+		for _, value := range T.Values {
+			r.walk(value)
+		}
+		for _, name := range T.Names {
+			r.declare(name)
+		}
+		return nil
+	case *ast.AssignStmt:
+		if T.Tok != token.DEFINE {
+			break
+		}
+		for _, expr := range T.Rhs {
+			r.walk(expr)
+		}
+		r.declare(T.Lhs...)
+		return nil
+	case *ast.RangeStmt:
+		r.walk(T.X)
+		s := r.scope()
+		if T.Tok == token.DEFINE {
+			s.declare(T.Key, T.Value)
+		} else {
+			s.walk(T.Key, T.Value)
+		}
+		s.walk(T.Body)
+		return nil
+	case *ast.TypeSwitchStmt:
+		s := r.scope()
+		s.walk(T.Init, T.Assign)
+		s.walk(T.Body)
+		return nil
+	case *ast.BlockStmt, *ast.IfStmt, *ast.ForStmt, *ast.SwitchStmt,
+		*ast.CaseClause, *ast.CommClause:
+		return r.scope()
+	}
+	return r
+}
@@ -0,0 +1,50 @@
+package astfrom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferencedPackages(t *testing.T) {
+	type test struct {
+		src string
+		exp []string
+	}
+	tests := []test{
+		{`x`, []string{}},
+		{`fmt.Println`, []string{`fmt`}},
+		{`fmt.Println(http.Get(u))`, []string{`fmt`, `http`}},
+		{`a.b.c(a.d)`, []string{`a`}},
+		{`x := t; x.Y()`, []string{}},
+		{`fmt.Sprint(); fmt := t; fmt.X()`, []string{`fmt`}},
+		{`fmt := fmt.Sprint(1)`, []string{`fmt`}},
+		{"if fmt := f(); fmt.X {\n} else {\n\t_ = fmt.Y\n}\nstrings.Join()", []string{`strings`}},
+		{"{\n\tfmt := t\n\t_ = fmt.X\n}\nfmt.Println()", []string{`fmt`}},
+		{`func(fmt int) { fmt.X() }`, []string{}},
+		{`func(s strings.Builder) (n io.Writer) { return n.X }`, []string{`io`, `strings`}},
+		{`for i, os := range os.Args { _ = os.X }`, []string{`os`}},
+		{`for _, s := range xs { s.Do() }; s.Do()`, []string{`s`}},
+		{`for i := 0; i < n; i++ { i.X() }`, []string{}},
+		{"switch v := x.(type) {\ncase fmt.Stringer:\n\t_ = v.String()\n}", []string{`fmt`}},
+		{`var os = 1; _ = os.X`, []string{}},
+		{"package p\n\nimport \"net/http\"\n\nvar c = http.Client{}\n\nfunc f() { c.Do(r) }", []string{`http`}},
+		{"package p\n\nfunc f() { g.X() }\n\nvar g T", []string{}},
+		{"package p\n\ntype T struct{ w io.Writer }\n\nfunc (t T) M() { t.w.Write(nil) }", []string{`io`}},
+		{"package p\n\nfunc G[T fmt.Stringer](v T) { v.String() }", []string{`fmt`}},
+		{`struct{ fmt string; x bytes.Buffer }`, []string{`bytes`}},
+		{`T{fmt: 1}.fmt`, []string{}},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v`, idx, test.src, test.exp)
+
+		if exp, got := test.exp, ReferencedPackages(Source(test.src)); !reflect.DeepEqual(exp, got) {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Nil`, func(t *testing.T) {
+		if got := ReferencedPackages(nil); got == nil || len(got) != 0 {
+			t.Fatalf(`exp empty non-nil result; got %#v`, got)
+		}
+	})
+}
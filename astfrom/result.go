@@ -0,0 +1,114 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// Result is a node parsed by SourceResult along with the context needed to
+// relate its positions back to the source it was parsed from.
+type Result struct {
+	// Node is the reduced node, as returned from Source.
+	Node ast.Node
+
+	// Kind is the kind src was expanded to for the successful parse.
+	Kind Kind
+
+	// Fset is the file set holding the positions of Node, which refer to the
+	// scaffolded source rather than src.
+	Fset *token.FileSet
+
+	m      *sourceMap // relates the trimmed src to the original src
+	offset int        // offset of the trimmed src within the scaffolded source
+	file   *token.File
+	raw    ast.Node // node before reduction
+}
+
+// SourceResult parses src like SourceErr, returning the node within a Result.
+func SourceResult(src string) (*Result, error) {
+	return Options{}.result(src, nil)
+}
+
+func (o Options) result(src string, fn func(Attempt)) (*Result, error) {
+	var last Attempt
+	fset := token.NewFileSet()
+	raw, node, err := o.parseRaw(fset, src, func(a Attempt) {
+		if last = a; fn != nil {
+			fn(a)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m, trimmed := o.sourceMap(src)
+	res := &Result{
+		Node:   node,
+		Kind:   last.Kind,
+		Fset:   fset,
+		m:      m,
+		offset: last.offset,
+		file:   fset.File(node.Pos()),
+		raw:    raw,
+	}
+	if len(trimmed) == 0 {
+		res.file = nil
+	}
+	return res, nil
+}
+
+// OriginalPos maps pos, a position of Result.Node or one of its children, to
+// the line and column it has within the original src. The Filename of the
+// returned position is empty and its Offset is the byte offset within src.
+//
+// Positions within the scaffolding added around src, such as those of the
+// sentinel func, are mapped to the invalid zero position. When src contains
+// template holes, positions within the identifier which replaced a hole are
+// mapped to its placeholder.
+func (r *Result) OriginalPos(pos token.Pos) token.Position {
+	if r.file == nil || !pos.IsValid() || r.Fset.File(pos) != r.file {
+		return token.Position{}
+	}
+	p, _ := r.m.original(r.file.Offset(pos) - r.offset)
+	return p
+}
+
+// NodeAt returns the innermost node of r.Node containing offset, a byte offset
+// within the original src. It returns nil when offset falls outside of the
+// parsed source, see the package level NodeAt.
+func (r *Result) NodeAt(offset int) ast.Node {
+	off, ok := r.m.trimmed(offset)
+	if r.file == nil || !ok {
+		return nil
+	}
+	return NodeAt(r.Node, r.Fset, off+r.offset)
+}
+
+// NodeAt returns the innermost node within node whose range contains offset,
+// a byte offset within the file of fset holding node. A node contains the
+// offsets from its Pos up to but not including its End, when siblings overlap
+// such as the name and type of a *ast.FuncDecl the narrower one is returned.
+// The fset must be the one node was parsed with, such as Result.Fset, and nil
+// is returned when no node contains offset.
+func NodeAt(node ast.Node, fset *token.FileSet, offset int) ast.Node {
+	if node == nil || fset == nil || !node.Pos().IsValid() {
+		return nil
+	}
+	file := fset.File(node.Pos())
+	if file == nil || offset < 0 || offset > file.Size() {
+		return nil
+	}
+
+	var found ast.Node
+	pos := file.Pos(offset)
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil || pos < n.Pos() || pos >= n.End() {
+			return false
+		}
+		if found == nil || (n.Pos() >= found.Pos() && n.End() <= found.End()) {
+			found = n
+		}
+		return true
+	})
+	return found
+}
@@ -0,0 +1,376 @@
+package astfrom
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestSourceResult(t *testing.T) {
+	type test struct {
+		src  string
+		kind Kind
+		name string
+		line int
+		col  int
+	}
+	tests := []test{
+		{`foo`, KindExpr, `foo`, 1, 1},
+		{`a + foo`, KindExpr, `foo`, 1, 5},
+		{`x := foo`, KindDecl, `foo`, 1, 6},
+		{"\n\n  x := foo", KindDecl, `foo`, 3, 8},
+		{"a := 1\nb := foo", KindDecl, `foo`, 2, 6},
+		{`a := 1; b := foo`, KindDecl, `foo`, 1, 14},
+		{"if x {\n\tfoo()\n}", KindDecl, `foo`, 2, 2},
+		{"\nfunc foo() {}\n\nfunc g() {}", KindFile, `foo`, 2, 6},
+		{"package main\n\nvar foo = 1", KindPkg, `foo`, 3, 5},
+		{`{1, foo}`, KindExpr, `foo`, 1, 5},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v at %v:%v`,
+			idx, test.src, test.name, test.line, test.col)
+
+		res, err := SourceResult(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceResult; got %v`, err)
+		}
+		if exp, got := test.kind, res.Kind; exp != got {
+			t.Fatalf(`exp kind %v; got %v`, exp, got)
+		}
+
+		var id *ast.Ident
+		ast.Inspect(res.Node, func(n ast.Node) bool {
+			if v, ok := n.(*ast.Ident); ok && v.Name == test.name && id == nil {
+				id = v
+			}
+			return id == nil
+		})
+		if id == nil {
+			t.Fatalf(`exp ident %v within node`, test.name)
+		}
+
+		pos := res.OriginalPos(id.Pos())
+		if exp, got := test.line, pos.Line; exp != got {
+			t.Fatalf(`exp line %v; got %v (scaffolded %v)`, exp, got, res.Fset.Position(id.Pos()))
+		}
+		if exp, got := test.col, pos.Column; exp != got {
+			t.Fatalf(`exp column %v; got %v (scaffolded %v)`, exp, got, res.Fset.Position(id.Pos()))
+		}
+		if exp, got := test.name, test.src[pos.Offset:pos.Offset+len(test.name)]; exp != got {
+			t.Fatalf(`exp offset %v to begin %q; got %q`, pos.Offset, exp, got)
+		}
+	}
+
+	t.Run(`Scaffolding`, func(t *testing.T) {
+		res, err := SourceResult("a := 1\nb := 2")
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceResult; got %v`, err)
+		}
+		block := res.Node.(*ast.BlockStmt)
+		if pos := res.OriginalPos(block.Lbrace); pos.IsValid() {
+			t.Fatalf(`exp scaffolded brace to map to an invalid position; got %v`, pos)
+		}
+		if pos := res.OriginalPos(token.NoPos); pos.IsValid() {
+			t.Fatalf(`exp NoPos to map to an invalid position; got %v`, pos)
+		}
+		if pos := res.OriginalPos(block.List[0].Pos()); pos.Line != 1 || pos.Column != 1 {
+			t.Fatalf(`exp first stmt at 1:1; got %v`, pos)
+		}
+	})
+	t.Run(`ExprViaFile`, func(t *testing.T) {
+		res, err := Options{ExprViaFile: true}.result(`a + foo`, nil)
+		if err != nil {
+			t.Fatalf(`exp nil err from result; got %v`, err)
+		}
+		pos := res.OriginalPos(res.Node.(*ast.BinaryExpr).Y.Pos())
+		if pos.Line != 1 || pos.Column != 5 {
+			t.Fatalf(`exp foo at 1:5; got %v`, pos)
+		}
+	})
+	t.Run(`Holes`, func(t *testing.T) {
+		type test struct {
+			src  string
+			ph   rune
+			name string
+			pos  string
+		}
+		tests := []test{
+			{`$a + b`, '$', `b`, `1:6`},
+			{`$a + b`, '$', holePrefix + `a`, `1:1`},
+			{`$a + $b + c`, '$', holePrefix + `b`, `1:6`},
+			{`$a + $b + c`, '$', `c`, `1:11`},
+			{"\n\tx := $v\n\ty := f($v, z)", '$', `z`, `3:13`},
+			{`§a + b`, '§', `b`, `1:7`},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q exp %v at %v`, idx, test.src, test.name, test.pos)
+
+			res, err := Options{Placeholder: test.ph}.result(test.src, nil)
+			if err != nil {
+				t.Fatalf(`exp nil err from result; got %v`, err)
+			}
+			var id *ast.Ident
+			ast.Inspect(res.Node, func(n ast.Node) bool {
+				if v, ok := n.(*ast.Ident); ok && v.Name == test.name && id == nil {
+					id = v
+				}
+				return id == nil
+			})
+			if id == nil {
+				t.Fatalf(`exp ident %v within node`, test.name)
+			}
+
+			pos := res.OriginalPos(id.Pos())
+			if exp, got := test.pos, fmt.Sprintf(`%v:%v`, pos.Line, pos.Column); exp != got {
+				t.Fatalf(`exp ident at %v; got %v`, exp, got)
+			}
+			if exp, got := id, res.NodeAt(pos.Offset); exp != got {
+				t.Fatalf(`exp NodeAt(%v) to return the ident; got %T`, pos.Offset, got)
+			}
+		}
+	})
+	t.Run(`Errors`, func(t *testing.T) {
+		if res, err := SourceResult(`{`); err == nil {
+			t.Fatalf(`exp non-nil err from SourceResult; got %v`, res.Node)
+		}
+
+		type test struct {
+			src  string
+			opts Options
+			exp  string
+		}
+		tests := []test{
+			{`x.(type)`, Options{}, `1:1: use of x.(type) outside type switch`},
+			{"\n\n  v := x.(type)", Options{}, `3:8: use of x.(type) outside type switch`},
+			{`$a + $b.(type)`, Options{Placeholder: '$'},
+				`1:6: use of astfromHole_b.(type) outside type switch`},
+			{"\n  x :=", Options{}, `2:3: expected 'package', found x`},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q exp err %q`, idx, test.src, test.exp)
+
+			_, err := test.opts.result(test.src, nil)
+			if err == nil {
+				t.Fatal(`exp non-nil err from result`)
+			}
+			if exp, got := test.exp, err.Error(); exp != got {
+				t.Fatalf(`exp err %q; got %q`, exp, got)
+			}
+		}
+	})
+}
+
+func TestNodeAt(t *testing.T) {
+	type test struct {
+		src    string
+		offset int
+		exp    string
+	}
+	tests := []test{
+		{`foo`, 0, `*ast.Ident foo`},
+		{`foo`, 3, `<nil>`},
+		{`a + foo`, 2, `*ast.BinaryExpr`},
+		{`a + foo`, 5, `*ast.Ident foo`},
+		{`x := foo(1)`, 9, `*ast.BasicLit`},
+		{`x := foo(1)`, 8, `*ast.CallExpr`},
+		{"a := 1\nb := foo", 12, `*ast.Ident foo`},
+		{"a := 1\nb := foo", 6, `*ast.BlockStmt`},
+		{"func f() {\n\treturn\n}", 12, `*ast.ReturnStmt`},
+		{"func f() {\n\treturn\n}", 5, `*ast.Ident f`},
+		{`{1, foo}`, 4, `*ast.Ident foo`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q at offset %v exp %v`,
+			idx, test.src, test.offset, test.exp)
+
+		res, err := SourceResult(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceResult; got %v`, err)
+		}
+
+		got := fmt.Sprintf(`%T`, res.NodeAt(test.offset))
+		if id, ok := res.NodeAt(test.offset).(*ast.Ident); ok {
+			got += ` ` + id.Name
+		}
+		if exp := test.exp; exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Scaffolded`, func(t *testing.T) {
+		fset := token.NewFileSet()
+		node, err := Options{}.parse(fset, `x := foo`, nil)
+		if err != nil {
+			t.Fatalf(`exp nil err from parse; got %v`, err)
+		}
+		off := fset.File(node.Pos()).Offset(node.Pos())
+		if got, ok := NodeAt(node, fset, off).(*ast.Ident); !ok || got.Name != `x` {
+			t.Fatalf(`exp ident x at scaffolded offset %v; got %v`, off, NodeAt(node, fset, off))
+		}
+		if got := NodeAt(node, fset, 0); got != nil {
+			t.Fatalf(`exp nil node within scaffolding; got %T`, got)
+		}
+		if got := NodeAt(node, token.NewFileSet(), off); got != nil {
+			t.Fatalf(`exp nil node for unrelated fset; got %T`, got)
+		}
+		if got := NodeAt(nil, fset, off); got != nil {
+			t.Fatalf(`exp nil node for nil node; got %T`, got)
+		}
+	})
+}
+
+func TestParse(t *testing.T) {
+	type test struct {
+		src  string
+		opts []Option
+		kind Kind
+		node ast.Node
+		raw  ast.Node
+		name string
+		pos  string
+	}
+	tests := []test{
+		{`a + foo`, nil, KindExpr, &ast.BinaryExpr{}, &ast.BinaryExpr{}, `foo`, `1:5`},
+		{`x := foo`, nil, KindDecl, &ast.AssignStmt{}, &ast.File{}, `foo`, `1:6`},
+		{"a := 1\nb := foo", nil, KindDecl, &ast.BlockStmt{}, &ast.File{}, `foo`, `2:6`},
+		{`foo`, []Option{WithPrefer(KindDecl)}, KindDecl, &ast.ExprStmt{}, &ast.File{},
+			`foo`, `1:1`},
+		{`fmt.Println(foo)`, []Option{WithImports(`fmt`)}, KindExpr, &ast.CallExpr{},
+			&ast.CallExpr{}, `foo`, `1:13`},
+		{`x := fmt.Sprint(foo)`, []Option{WithImports(`fmt`), WithImports(`io`)}, KindDecl,
+			&ast.AssignStmt{}, &ast.File{}, `foo`, `1:17`},
+		{"// Doc\nfunc foo() {}", []Option{WithMode(parser.ParseComments)}, KindFile,
+			&ast.FuncDecl{}, &ast.File{}, `foo`, `2:6`},
+		{"package p\n\nvar foo = 1", []Option{WithOptions(Options{Aggressive: true})},
+			KindPkg, &ast.GenDecl{}, &ast.File{}, `foo`, `3:5`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %v at %v`, idx, test.src, test.name, test.pos)
+
+		res := Parse(test.src, test.opts...)
+		if len(res.Errors) > 0 || res.Err != nil {
+			t.Fatalf(`exp no errors; got %v, %v`, res.Errors, res.Err)
+		}
+		if exp, got := test.kind, res.Kind; exp != got {
+			t.Fatalf(`exp kind %v; got %v`, exp, got)
+		}
+		if len(res.Attempts) == 0 {
+			t.Fatal(`exp attempts`)
+		}
+		if last := res.Attempts[len(res.Attempts)-1]; last.Kind != res.Kind || last.Err != nil {
+			t.Fatalf(`exp final attempt of kind %v to succeed; got %v, %v`,
+				res.Kind, last.Kind, last.Err)
+		}
+		if exp, got := reflect.TypeOf(test.node), reflect.TypeOf(res.Node); exp != got {
+			t.Fatalf(`exp node type %v; got %v`, exp, got)
+		}
+		if exp, got := reflect.TypeOf(test.raw), reflect.TypeOf(res.Raw); exp != got {
+			t.Fatalf(`exp raw type %v; got %v`, exp, got)
+		}
+
+		var found bool
+		ast.Inspect(res.Raw, func(n ast.Node) bool {
+			found = found || n == res.Node
+			return !found
+		})
+		if !found {
+			t.Fatal(`exp node to be within raw`)
+		}
+
+		var id *ast.Ident
+		ast.Inspect(res.Node, func(n ast.Node) bool {
+			if v, ok := n.(*ast.Ident); ok && v.Name == test.name && id == nil {
+				id = v
+			}
+			return id == nil
+		})
+		if id == nil {
+			t.Fatalf(`exp ident %v within node`, test.name)
+		}
+		pos := res.OriginalPos(id.Pos())
+		if exp, got := test.pos, fmt.Sprintf(`%v:%v`, pos.Line, pos.Column); exp != got {
+			t.Fatalf(`exp ident at %v; got %v (scaffolded %v)`,
+				exp, got, res.Fset.Position(id.Pos()))
+		}
+	}
+
+	t.Run(`Errors`, func(t *testing.T) {
+		type test struct {
+			src  string
+			opts []Option
+			exp  []string
+		}
+		tests := []test{
+			{`x := `, nil, []string{`1:5: expected operand, found '}'`}},
+			{"\n\tx := 1\n\ty := )", nil, []string{
+				`3:7: expected operand, found ')'`, `3:8: expected ';', found 'EOF'`}},
+			{"x := 1\ny := )\nz := ]", nil, []string{
+				`2:6: expected operand, found ')'`, `3:7: expected ';', found 'EOF'`}},
+			{`f(,) + g(,)`, []Option{WithMode(parser.AllErrors)}, []string{
+				`1:3: expected operand, found ','`, `1:12: expected ')', found 'EOF'`,
+				`1:12: missing ',' in argument list`}},
+			{"package p\n\nfunc {", nil, []string{`3:6: expected 'IDENT', found '{'`}},
+			{`$a := $b.(type)`, []Option{WithOptions(Options{Placeholder: '$'})}, []string{
+				`1:7: use of astfromHole_b.(type) outside type switch`}},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - from src %q exp errors %q`, idx, test.src, test.exp)
+
+			res := Parse(test.src, test.opts...)
+			got := make([]string, len(res.Errors))
+			for i, err := range res.Errors {
+				if _, ok := err.(*scanner.Error); !ok {
+					t.Fatalf(`exp *scanner.Error; got %T`, err)
+				}
+				got[i] = err.Error()
+			}
+			if exp := test.exp; !reflect.DeepEqual(exp, got) {
+				t.Fatalf("\n---- [exp] ----\n%q\n\n---- [got] ----\n%q\n", exp, got)
+			}
+		}
+
+		res := Parse(`x :=`)
+		if _, err := SourceErr(`x :=`); res.Node.(*ast.Ident).Name != err.Error() {
+			t.Fatalf(`exp *ast.Ident holding the err; got %v`, res.Node)
+		}
+		if _, err := SourceErr(`x :=`); res.Err == nil || res.Err.Error() != err.Error() {
+			t.Fatalf(`exp err %v; got %v`, err, res.Err)
+		}
+		if _, attempts := SourceTrace(`x :=`); len(res.Attempts) != len(attempts) {
+			t.Fatalf(`exp %v attempts; got %v`, len(attempts), len(res.Attempts))
+		}
+		if res.Raw != nil || res.Fset == nil || res.Kind != KindNode {
+			t.Fatalf(`exp nil raw, non-nil fset and kind %v; got %T, %v, %v`,
+				KindNode, res.Raw, res.Fset, res.Kind)
+		}
+		if exp, got := (token.Position{}), res.OriginalPos(1); exp != got {
+			t.Fatalf(`exp zero position; got %v`, got)
+		}
+
+		res = Parse("x :=\n\t)", WithOptions(Options{OnError: ErrorBadExpr}))
+		bad, ok := res.Node.(*ast.BadExpr)
+		if !ok {
+			t.Fatalf(`exp *ast.BadExpr; got %T`, res.Node)
+		}
+		if exp, got := `1:1`, res.Fset.Position(bad.From).String(); exp != got {
+			t.Fatalf(`exp bad expr from %v; got %v`, exp, got)
+		}
+		if exp, got := `2:3`, res.Fset.Position(bad.To).String(); exp != got {
+			t.Fatalf(`exp bad expr to %v; got %v`, exp, got)
+		}
+
+		res = Parse(`x :=`, WithOptions(Options{OnError: ErrorNil}))
+		if res.Node != nil || len(res.Errors) != 1 {
+			t.Fatalf(`exp nil node and an error; got %T, %v`, res.Node, res.Errors)
+		}
+		res = Parse(`x`, WithMode(parser.ImportsOnly))
+		if len(res.Errors) != 1 || errors.Is(res.Errors[0], ErrUnparseable) {
+			t.Fatalf(`exp an unsupported mode error; got %v`, res.Errors)
+		}
+	})
+}
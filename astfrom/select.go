@@ -0,0 +1,77 @@
+package astfrom
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Select returns the node reached by following path from node. The path is a
+// sequence of steps separated by dots, each the name of a field or the index of
+// a slice element, so `Decls.0.Body` selects the body of the first declaration
+// of a *ast.File. Map fields such as the Files of a *ast.Package are stepped
+// into by key. An empty path returns node.
+//
+// An error is returned when a step names no field or element, steps through a
+// nil value, or when the path ends at a value which isn't a node such as the
+// Name string of an *ast.Ident.
+func Select(node ast.Node, path string) (ast.Node, error) {
+	if node == nil {
+		return nil, fmt.Errorf("cannot select %q from nil node", path)
+	}
+	if len(path) == 0 {
+		return node, nil
+	}
+
+	v := reflect.ValueOf(node)
+	steps := strings.Split(path, ".")
+	for idx, step := range steps {
+		at := strings.Join(steps[:idx], ".")
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil, fmt.Errorf("cannot select %q from nil value at %q", step, at)
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			f, ok := v.Type().FieldByName(step)
+			if !ok || f.PkgPath != `` {
+				return nil, fmt.Errorf("no field %q in %v at %q", step, v.Type(), at)
+			}
+			v = v.FieldByIndex(f.Index)
+		case reflect.Slice:
+			i, err := strconv.Atoi(step)
+			if err != nil || i < 0 || i >= v.Len() {
+				return nil, fmt.Errorf("invalid index %q for %v of length %v at %q",
+					step, v.Type(), v.Len(), at)
+			}
+			v = v.Index(i)
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("cannot select %q from %v at %q", step, v.Type(), at)
+			}
+			key := reflect.ValueOf(step).Convert(v.Type().Key())
+			if v = v.MapIndex(key); !v.IsValid() {
+				return nil, fmt.Errorf("no key %q in map at %q", step, at)
+			}
+		default:
+			return nil, fmt.Errorf("cannot select %q from %v at %q", step, v.Type(), at)
+		}
+	}
+
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, fmt.Errorf("nil value at %q", path)
+	}
+	if !v.CanInterface() {
+		return nil, fmt.Errorf("cannot select unexported value at %q", path)
+	}
+	sel, ok := v.Interface().(ast.Node)
+	if !ok {
+		return nil, fmt.Errorf("%v at %q is not a node", v.Type(), path)
+	}
+	return sel, nil
+}
@@ -0,0 +1,85 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	type test struct {
+		src  string
+		path string
+		exp  string
+	}
+	tests := []test{
+		{`foo`, ``, `foo`},
+		{`a + b*c`, `Y`, `b * c`},
+		{`a + b*c`, `Y.X`, `b`},
+		{`f(1, 2)`, `Args.1`, `2`},
+		{`x := f(y)`, `Rhs.0.Fun`, `f`},
+		{"func f() {\n\treturn 1\n}", `Body.List.0`, `return 1`},
+		{"package p\n\nvar x = 1\n\nfunc g() { h() }", `Decls.1.Body`, "{\n\th()\n}"},
+		{"package p\n\nvar x = 1", `Decls.0.Specs.0.Values.0`, `1`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q select %q`, idx, test.src, test.path)
+
+		node, err := Select(Source(test.src), test.path)
+		if err != nil {
+			t.Fatalf(`exp nil err from Select; got %v`, err)
+		}
+		if exp, got := test.exp, sprint(t, node); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Package`, func(t *testing.T) {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, `a.go`, `package p; var x = 1`, 0)
+		if err != nil {
+			t.Fatalf(`exp nil err from ParseFile; got %v`, err)
+		}
+		pkg := &ast.Package{Name: `p`, Files: map[string]*ast.File{`a.go`: f}}
+		node, err := Select(pkg, `Files.a.go`)
+		if err == nil {
+			t.Fatalf(`exp dotted map key to be split; got %T`, node)
+		}
+
+		pkg.Files = map[string]*ast.File{`a`: f}
+		node, err = Select(pkg, `Files.a.Name`)
+		if err != nil {
+			t.Fatalf(`exp nil err from Select; got %v`, err)
+		}
+		if exp, got := `p`, node.(*ast.Ident).Name; exp != got {
+			t.Fatalf(`exp package name %v; got %v`, exp, got)
+		}
+	})
+	t.Run(`Errors`, func(t *testing.T) {
+		type test struct {
+			node ast.Node
+			path string
+		}
+		tests := []test{
+			{nil, ``},
+			{Source(`foo`), `Name`},
+			{Source(`foo`), `Obj`},
+			{Source(`foo`), `Missing`},
+			{Source(`f(1)`), `Args.1`},
+			{Source(`f(1)`), `Args.-1`},
+			{Source(`f(1)`), `Args.x`},
+			{Source(`f(1)`), `Fun.Name.Len`},
+			{Source(`func() {}`), `Type.Results`},
+			{Source(`func() {}`), `Type.Results.List`},
+			{Source(`func() {}`), `Type.Params.List`},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - select %q from %T`, idx, test.path, test.node)
+
+			if node, err := Select(test.node, test.path); err == nil {
+				t.Fatalf(`exp non-nil err from Select; got %T`, node)
+			}
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/token"
+	"sync"
+)
+
+// Session parses successive versions of a single buffer, such as the contents
+// of an editor on each keystroke. Edits rarely change the kind of a buffer, so
+// a parse of source sharing a long common prefix with the last successfully
+// parsed source, at least half the length of the shorter of the two, begins
+// the climb at the kind of the last successful parse rather than KindExpr,
+// skipping the attempts that failed before. Other source, such as a buffer
+// replaced entirely, climbs as if it were the first. When the last kind now
+// fails the climb continues as described by Options.Prefer, so any source
+// valid for Source is still parsed.
+//
+// Because the climb starts at the last kind, a buffer edited down to a smaller
+// kind is parsed at the larger one where valid, for example `x` after `x := 1`
+// is an *ast.ExprStmt rather than an *ast.Ident. The zero value is ready to use
+// and a Session is safe for concurrent use.
+type Session struct {
+	// Options configures each parse, with Prefer used only until the first
+	// successful parse.
+	Options Options
+
+	mu   sync.Mutex
+	src  string
+	node ast.Node
+	kind Kind
+}
+
+// Kind returns the kind of the last successful parse, or KindNode if there
+// hasn't been one.
+func (s *Session) Kind() Kind {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kind
+}
+
+// Source parses src like SourceWith, returning the node selected by
+// Options.OnError along with the error when src fails to parse. When src is
+// identical to the last successfully parsed source the same node is returned
+// without parsing, so it should not be modified by the caller.
+func (s *Session) Source(src string) (ast.Node, error) {
+	node, _, err := s.trace(src)
+	return node, err
+}
+
+// Trace behaves like Source but returns each parse attempt made instead of
+// the error, which is empty when the node of the last parse was reused.
+func (s *Session) Trace(src string) (ast.Node, []Attempt) {
+	node, attempts, _ := s.trace(src)
+	return node, attempts
+}
+
+func (s *Session) trace(src string) (ast.Node, []Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.node != nil && s.src == src {
+		return s.node, nil, nil
+	}
+
+	opts := s.Options
+	if s.kind != KindNode && s.edited(src) {
+		opts.Prefer = s.kind
+	}
+
+	var attempts []Attempt
+	node, err := opts.parse(token.NewFileSet(), src, func(a Attempt) {
+		attempts = append(attempts, a)
+	})
+	if err != nil {
+		return opts.failed(src, err), attempts, err
+	}
+	s.src, s.node, s.kind = src, node, attempts[len(attempts)-1].Kind
+	return node, attempts, nil
+}
+
+// edited reports whether src shares a common prefix with the last successfully
+// parsed source at least half the length of the shorter of the two, as an edit
+// of that source does.
+func (s *Session) edited(src string) bool {
+	var n int
+	for n < len(src) && n < len(s.src) && src[n] == s.src[n] {
+		n++
+	}
+	short := len(src)
+	if len(s.src) < short {
+		short = len(s.src)
+	}
+	return n > 0 && 2*n >= short
+}
@@ -0,0 +1,87 @@
+package astfrom
+
+import (
+	"go/ast"
+	"reflect"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	type test struct {
+		src   string
+		kinds []Kind
+		kind  Kind
+		fail  bool
+	}
+	tests := []test{
+		{`x := 1`, []Kind{KindExpr, KindDecl}, KindDecl, false},
+		{"x := 1\ny := 2", []Kind{KindDecl}, KindDecl, false},
+		{"x := 1\ny := 2", nil, KindDecl, false},
+		{"x := 1\ny :=", []Kind{
+			KindDecl, KindStmt, KindBlock, KindFile, KindPkg, KindExpr}, KindDecl, true},
+		{"x := 1\ny := x", []Kind{KindDecl}, KindDecl, false},
+		{"x := 1\nfunc f() {}", []Kind{
+			KindDecl, KindStmt, KindBlock, KindFile, KindPkg, KindExpr}, KindDecl, true},
+		{"x := 1\n\tfunc() {}()", []Kind{KindDecl}, KindDecl, false},
+		{`func f() {}`, []Kind{KindExpr, KindDecl, KindStmt, KindBlock, KindFile}, KindFile, false},
+		{"func f() {}\n\nfunc g() {}", []Kind{KindFile}, KindFile, false},
+		{"func f() {}\n\nfunc g() { f() }", []Kind{KindFile}, KindFile, false},
+		{"func f() {}\n\nfunc g() { f(); f() }", []Kind{KindFile}, KindFile, false},
+		{"package p\n\nfunc f() {}", []Kind{
+			KindExpr, KindDecl, KindStmt, KindBlock, KindFile, KindPkg}, KindPkg, false},
+		{"package p\n\nfunc f() {}\nfunc g() {}", []Kind{KindPkg}, KindPkg, false},
+	}
+
+	var (
+		s    Session
+		last ast.Node
+	)
+	if exp, got := KindNode, s.Kind(); exp != got {
+		t.Fatalf(`exp zero Session to have kind %v; got %v`, exp, got)
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - exp attempts %v from src %q`, idx, test.kinds, test.src)
+
+		node, attempts := s.Trace(test.src)
+		var kinds []Kind
+		for _, a := range attempts {
+			kinds = append(kinds, a.Kind)
+		}
+		if exp, got := test.kinds, kinds; !reflect.DeepEqual(exp, got) {
+			t.Fatalf(`exp attempts at %v; got %v`, exp, got)
+		}
+		if exp, got := test.kind, s.Kind(); exp != got {
+			t.Fatalf(`exp session kind %v; got %v`, exp, got)
+		}
+		if test.fail {
+			if _, err := s.Source(test.src); err == nil {
+				t.Fatal(`exp non-nil err from Source`)
+			}
+			continue
+		}
+		if len(attempts) == 0 && node != last {
+			t.Fatal(`exp node of identical src to be reused`)
+		}
+		if exp, got := sprint(t, Source(test.src)), sprint(t, node); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+		last = node
+	}
+
+	t.Run(`Smaller`, func(t *testing.T) {
+		s := Session{Options: Options{OnError: ErrorNil}}
+		if _, err := s.Source(`x := 1`); err != nil {
+			t.Fatalf(`exp nil err from Source; got %v`, err)
+		}
+		node, err := s.Source(`x`)
+		if err != nil {
+			t.Fatalf(`exp nil err from Source; got %v`, err)
+		}
+		if _, ok := node.(*ast.ExprStmt); !ok {
+			t.Fatalf(`exp *ast.ExprStmt after a statement; got %T`, node)
+		}
+		if node, err = s.Source(`x :=`); node != nil || err == nil {
+			t.Fatalf(`exp nil node and non-nil err; got %T, %v`, node, err)
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package astfrom
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+)
+
+// Splice replaces each *ast.Ident named placeholder within into by a copy of
+// replacement, returning the resulting node. The into node is modified in
+// place, and is only replaced itself when it's the placeholder. Template holes
+// created by Options.Placeholder are matched by the name following the
+// placeholder, so `$x` is spliced with the placeholder "x".
+//
+// A placeholder used as an expression statement, such as `x` within the block
+// `{ x; return }`, may be replaced by a statement. Otherwise the replacement
+// must be valid for the field holding the placeholder: an expression where an
+// expression is expected, or an identifier where only an identifier is valid
+// such as the name of a func. An error is returned for an invalid replacement
+// or when into contains no placeholder.
+//
+// Each copy of replacement has its positions cleared so that it formats
+// without regard to the source it was parsed from.
+func Splice(into ast.Node, placeholder string, replacement ast.Node) (ast.Node, error) {
+	if into == nil || replacement == nil {
+		return nil, fmt.Errorf("cannot splice %T into %T", replacement, into)
+	}
+
+	s := &splicer{name: placeholder, repl: replacement}
+	if s.match(into) {
+		return s.copy(), nil
+	}
+
+	v := reflect.ValueOf(into)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot splice into %T", into)
+	}
+	if err := s.node(v); err != nil {
+		return nil, err
+	}
+	if s.count == 0 {
+		return nil, fmt.Errorf("placeholder %q not found in %T", placeholder, into)
+	}
+	return into, nil
+}
+
+// splicer replaces the placeholder nodes of a tree, counting each replacement.
+type splicer struct {
+	name  string
+	repl  ast.Node
+	count int
+}
+
+// match reports whether node should be replaced, which is the placeholder
+// ident itself or, when the replacement is a statement, an expression
+// statement of the placeholder.
+func (s *splicer) match(node ast.Node) bool {
+	switch T := node.(type) {
+	case *ast.Ident:
+		return T.Name == s.name || T.Name == holePrefix+s.name
+	case *ast.ExprStmt:
+		if _, ok := s.repl.(ast.Stmt); ok {
+			return s.match(T.X)
+		}
+	}
+	return false
+}
+
+func (s *splicer) copy() ast.Node {
+	return copyValue(reflect.ValueOf(s.repl)).Interface().(ast.Node)
+}
+
+// node splices each field of v, a pointer to a node struct.
+func (s *splicer) node(v reflect.Value) error {
+	elem := v.Elem()
+	for i := 0; i < elem.NumField(); i++ {
+		f, sf := elem.Field(i), elem.Type().Field(i)
+		if ignoredField(sf) {
+			continue
+		}
+
+		switch f.Kind() {
+		case reflect.Interface, reflect.Ptr:
+			if err := s.value(f, sf.Name, v.Type()); err != nil {
+				return err
+			}
+		case reflect.Slice:
+			for j := 0; j < f.Len(); j++ {
+				name := fmt.Sprintf("%v[%d]", sf.Name, j)
+				if err := s.value(f.Index(j), name, v.Type()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// value splices f, the field name of a node of type owner.
+func (s *splicer) value(f reflect.Value, name string, owner reflect.Type) error {
+	if f.IsNil() {
+		return nil
+	}
+	node, ok := f.Interface().(ast.Node)
+	if !ok {
+		return nil
+	}
+	if s.match(node) {
+		repl := reflect.ValueOf(s.copy())
+		if !repl.Type().AssignableTo(f.Type()) {
+			return fmt.Errorf("cannot splice %T as %v of %v", s.repl, name, owner)
+		}
+		f.Set(repl)
+		s.count++
+		return nil
+	}
+
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return s.node(v)
+}
+
+// MapLiterals replaces each *ast.BasicLit within node by the literal returned
+// from fn, returning the resulting node. The node is modified in place, and is
+// only replaced itself when it's a literal. Literals of every kind are visited
+// in source order, including those held by fields typed as *ast.BasicLit such
+// as the Path of an *ast.ImportSpec and the Tag of an *ast.Field. When fn
+// returns nil the literal is kept unchanged.
+//
+// A returned literal without a valid ValuePos is given the position of the one
+// it replaces, so the node still formats with the line breaks of its source.
+// The Value of a returned literal must be valid Go source for its Kind, such as
+// a quoted string for token.STRING.
+func MapLiterals(node ast.Node, fn func(*ast.BasicLit) *ast.BasicLit) ast.Node {
+	if lit, ok := node.(*ast.BasicLit); ok {
+		if repl := mapLiteral(lit, fn); repl != nil {
+			return repl
+		}
+		return node
+	}
+	if v := reflect.ValueOf(node); v.IsValid() && v.Kind() == reflect.Ptr && !v.IsNil() {
+		mapLiterals(v, fn)
+	}
+	return node
+}
+
+// mapLiteral returns the replacement of lit from fn, or nil to keep lit.
+func mapLiteral(lit *ast.BasicLit, fn func(*ast.BasicLit) *ast.BasicLit) *ast.BasicLit {
+	repl := fn(lit)
+	if repl != nil && !repl.ValuePos.IsValid() {
+		repl.ValuePos = lit.ValuePos
+	}
+	return repl
+}
+
+// mapLiterals replaces the literals held by v, a pointer to a node struct.
+func mapLiterals(v reflect.Value, fn func(*ast.BasicLit) *ast.BasicLit) {
+	elem := v.Elem()
+	if elem.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < elem.NumField(); i++ {
+		f, sf := elem.Field(i), elem.Type().Field(i)
+		if ignoredField(sf) {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Interface, reflect.Ptr:
+			mapField(f, fn)
+		case reflect.Slice:
+			for j := 0; j < f.Len(); j++ {
+				mapField(f.Index(j), fn)
+			}
+		}
+	}
+}
+
+// mapField replaces f when it holds a literal, or the literals within it.
+func mapField(f reflect.Value, fn func(*ast.BasicLit) *ast.BasicLit) {
+	if f.IsNil() {
+		return
+	}
+	switch T := f.Interface().(type) {
+	case *ast.BasicLit:
+		if repl := mapLiteral(T, fn); repl != nil {
+			f.Set(reflect.ValueOf(repl))
+		}
+	case ast.Node:
+		if v := reflect.ValueOf(T); v.Kind() == reflect.Ptr && !v.IsNil() {
+			mapLiterals(v, fn)
+		}
+	}
+}
+
+// copyValue returns a deep copy of v with all positions cleared, except those
+// which carry meaning through their presence, and the object resolution fields
+// removed.
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || ignored(v.Type()) {
+			return reflect.Zero(v.Type())
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(copyValue(v.Elem()))
+		return out
+	case reflect.Interface:
+		out := reflect.New(v.Type()).Elem()
+		if !v.IsNil() {
+			out.Set(copyValue(v.Elem()))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(copyValue(v.Index(i)))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if !ignoredField(v.Type().Field(i)) {
+				out.Field(i).Set(copyValue(v.Field(i)))
+			}
+		}
+		return out
+	}
+	return v
+}
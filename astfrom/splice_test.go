@@ -0,0 +1,184 @@
+package astfrom
+
+import (
+	"go/ast"
+	"go/format"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSplice(t *testing.T) {
+	type test struct {
+		into string
+		repl string
+		exp  string
+	}
+	tests := []test{
+		{`X`, `f(a)`, `f(a)`},
+		{`X + 1`, `f(a)`, `f(a) + 1`},
+		{`X * X`, `a + b`, `(a + b) * (a + b)`},
+		{`x := X`, `[]int{1, 2}`, `x := []int{1, 2}`},
+		{`foo(X, y...)`, `a.b`, `foo(a.b, y...)`},
+		{`func X() {}`, `bar`, "func bar() {\n}"},
+		{`if X { y() }`, `a && b`, "if a && b {\n\ty()\n}"},
+		{"func f() {\n\tX\n\treturn\n}", `x := 1`, "func f() {\n\tx := 1\n\treturn\n}"},
+		{"func f() {\n\tX\n}", `g(xs...)`, "func f() {\n\tg(xs...)\n}"},
+		{`x := Y`, `f()`, `x := Y`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - splice %q into %q exp %q`, idx, test.repl, test.into, test.exp)
+
+		into := Source(test.into)
+		got, err := Splice(into, `X`, Source(test.repl))
+		if test.exp == test.into {
+			if err == nil {
+				t.Fatal(`exp non-nil err from Splice without a placeholder`)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf(`exp nil err from Splice; got %v`, err)
+		}
+		if exp, got := test.exp, sprint(t, got); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Copies`, func(t *testing.T) {
+		repl := Source(`a + b`)
+		got, err := Splice(Source(`X * X`), `X`, repl)
+		if err != nil {
+			t.Fatalf(`exp nil err from Splice; got %v`, err)
+		}
+		bin := got.(*ast.BinaryExpr)
+		if bin.X == bin.Y || bin.X == repl {
+			t.Fatal(`exp each placeholder replaced by a distinct copy`)
+		}
+		if !Equal(bin.X, repl) || !Equal(bin.Y, repl) {
+			t.Fatal(`exp each copy to be Equal to the replacement`)
+		}
+		if bin.X.Pos().IsValid() {
+			t.Fatalf(`exp copy positions cleared; got %v`, bin.X.Pos())
+		}
+	})
+	t.Run(`Holes`, func(t *testing.T) {
+		into := SourceWith(`$x := $y + 1`, Options{Placeholder: '$'})
+		got, err := Splice(into, `y`, Source(`len(s)`))
+		if err != nil {
+			t.Fatalf(`exp nil err from Splice; got %v`, err)
+		}
+		if exp, got := holePrefix+`x := len(s) + 1`, sprint(t, got); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	})
+	t.Run(`Errors`, func(t *testing.T) {
+		type test struct {
+			into string
+			repl string
+		}
+		tests := []test{
+			{`x := X`, `y := 2`},
+			{`X + 1`, `var y int`},
+			{`func X() {}`, `a.b`},
+			{`x.X`, `f()`},
+			{`x := 1`, `f()`},
+		}
+		for idx, test := range tests {
+			t.Logf(`test #%v - splice %q into %q`, idx, test.repl, test.into)
+
+			if got, err := Splice(Source(test.into), `X`, Source(test.repl)); err == nil {
+				t.Fatalf(`exp non-nil err from Splice; got %v`, sprint(t, got))
+			}
+		}
+		if _, err := Splice(nil, `X`, Source(`x`)); err == nil {
+			t.Fatal(`exp non-nil err from Splice with nil into`)
+		}
+		if _, err := Splice(Source(`X`), `X`, nil); err == nil {
+			t.Fatal(`exp non-nil err from Splice with nil replacement`)
+		}
+	})
+}
+
+func TestMapLiterals(t *testing.T) {
+	redact := func(lit *ast.BasicLit) *ast.BasicLit {
+		if lit.Kind != token.STRING {
+			return nil
+		}
+		return &ast.BasicLit{Kind: token.STRING, Value: `"***"`}
+	}
+	double := func(lit *ast.BasicLit) *ast.BasicLit {
+		v, err := LitValue(lit)
+		if err != nil {
+			t.Fatalf(`exp nil err from LitValue; got %v`, err)
+		}
+		switch T := v.(type) {
+		case int64:
+			return &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(T*2, 10)}
+		case float64:
+			return &ast.BasicLit{Kind: token.FLOAT, Value: strconv.FormatFloat(T*2, 'g', -1, 64)}
+		case complex128:
+			return &ast.BasicLit{Kind: token.IMAG, Value: strconv.FormatFloat(imag(T)*2, 'g', -1, 64) + `i`}
+		case rune:
+			return &ast.BasicLit{Kind: token.CHAR, Value: strconv.QuoteRune(T + 1)}
+		case string:
+			return &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(T + T)}
+		}
+		return nil
+	}
+
+	type test struct {
+		src string
+		fn  func(*ast.BasicLit) *ast.BasicLit
+		exp string
+	}
+	tests := []test{
+		{`"secret"`, redact, `"***"`},
+		{`1`, redact, `1`},
+		{`f("a", 1, "b")`, redact, `f("***", 1, "***")`},
+		{"log(`raw`, x)", redact, `log("***", x)`},
+		{"x := \"a\" +\n\t\"b\"", redact, "x := \"***\" +\n\t\"***\""},
+		{"package p\n\nimport \"fmt\"\n\ntype T struct {\n\tX int `json:\"x\"`\n}", redact,
+			"package p\n\nimport \"***\"\n\ntype T struct {\n\tX int \"***\"\n}\n"},
+		{`[]interface{}{1, 1.5, 2i, 'a', "s"}`, double, `[]interface{}{2, 3, 4i, 'b', "ss"}`},
+		{`switch x { case 1, 2: f(0x10) }`, double, "switch x {\ncase 2, 4:\n\tf(32)\n}"},
+		{`x := T{K: 1}`, func(*ast.BasicLit) *ast.BasicLit { return nil }, `x := T{K: 1}`},
+	}
+	for idx, test := range tests {
+		t.Logf(`test #%v - from src %q exp %q`, idx, test.src, test.exp)
+
+		res, err := SourceResult(test.src)
+		if err != nil {
+			t.Fatalf(`exp nil err from SourceResult; got %v`, err)
+		}
+		node := MapLiterals(res.Node, test.fn)
+
+		var buf strings.Builder
+		if err := format.Node(&buf, res.Fset, node); err != nil {
+			t.Fatalf(`exp nil err from format.Node; got %v`, err)
+		}
+		if exp, got := test.exp, buf.String(); exp != got {
+			t.Fatalf("\n---- [exp] ----\n%v\n\n---- [got] ----\n%v\n", exp, got)
+		}
+	}
+
+	t.Run(`Order`, func(t *testing.T) {
+		var got []string
+		MapLiterals(Source(`f(1, g(2, 3), []int{4}[0])`), func(lit *ast.BasicLit) *ast.BasicLit {
+			got = append(got, lit.Value)
+			return nil
+		})
+		if exp := `1 2 3 4 0`; exp != strings.Join(got, ` `) {
+			t.Fatalf(`exp literals in order %v; got %v`, exp, got)
+		}
+	})
+	t.Run(`Nil`, func(t *testing.T) {
+		if node := MapLiterals(nil, redact); node != nil {
+			t.Fatalf(`exp nil node; got %T`, node)
+		}
+		if node := MapLiterals((*ast.CallExpr)(nil), redact); node.(*ast.CallExpr) != nil {
+			t.Fatalf(`exp nil *ast.CallExpr; got %v`, node)
+		}
+	})
+}
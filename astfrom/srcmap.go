@@ -0,0 +1,117 @@
+package astfrom
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// sourceMap relates offsets within the trimmed source placed in the
+// scaffolding of each attempt to the original source given by the caller.
+type sourceMap struct {
+	src   string     // original source
+	lead  int        // bytes trimmed from the front of the replaced source
+	size  int        // bytes of the trimmed source
+	holes []holeEdit // hole replacements in source order
+}
+
+// holeEdit is the replacement of a placeholder by holePrefix.
+type holeEdit struct {
+	orig  int // offset of the placeholder within the original source
+	at    int // offset of holePrefix within the replaced source
+	delta int // bytes added by the replacement
+}
+
+// sourceMap returns the source map for src along with the trimmed source
+// which is placed in the scaffolding, after stripping any shebang and
+// replacing template holes.
+func (o Options) sourceMap(src string) (*sourceMap, string) {
+	replaced, holes := o.replaceHoleEdits(o.stripShebang(src))
+	trimmed := trimLines(replaced)
+	m := &sourceMap{
+		src:   src,
+		lead:  strings.Index(replaced, trimmed),
+		size:  len(trimmed),
+		holes: holes,
+	}
+	if len(trimmed) == 0 {
+		m.lead = 0
+	}
+	return m, trimmed
+}
+
+// original returns the position within the original source of off, an offset
+// within the trimmed source, reporting false when off falls outside of it.
+func (m *sourceMap) original(off int) (token.Position, bool) {
+	if off < 0 || off > m.size {
+		return token.Position{}, false
+	}
+	return m.position(off), true
+}
+
+// position returns the position within the original source of off, an offset
+// within the trimmed source which is clamped to its bounds. Offsets within the
+// identifier replacing a placeholder map to the placeholder.
+func (m *sourceMap) position(off int) token.Position {
+	if off < 0 {
+		off = 0
+	}
+	if off > m.size {
+		off = m.size
+	}
+
+	off += m.lead
+	rep := off
+	for _, h := range m.holes {
+		if rep < h.at+len(holePrefix) {
+			if rep > h.at {
+				off = h.orig
+			}
+			break
+		}
+		off -= h.delta
+	}
+	if off > len(m.src) {
+		off = len(m.src)
+	}
+
+	before := m.src[:off]
+	line := strings.Count(before, "\n") + 1
+	col := off - strings.LastIndexByte(before, '\n')
+	return token.Position{Offset: off, Line: line, Column: col}
+}
+
+// trimmed returns the offset within the trimmed source of off, an offset
+// within the original source, reporting false when off falls outside of it.
+func (m *sourceMap) trimmed(off int) (int, bool) {
+	if off < 0 || off > len(m.src) {
+		return 0, false
+	}
+	rep := off
+	for _, h := range m.holes {
+		if h.orig >= off {
+			break
+		}
+		rep += h.delta
+	}
+	if rep -= m.lead; rep < 0 || rep > m.size {
+		return 0, false
+	}
+	return rep, true
+}
+
+// mapErr returns err with the position of each entry of a scanner.ErrorList
+// mapped from the source of a to the original source. The positions of the
+// scaffolding surrounding the source are clamped to its bounds, while errors
+// without positions are returned unchanged.
+func (m *sourceMap) mapErr(err error, a Attempt) error {
+	list, ok := err.(scanner.ErrorList)
+	if !ok || m == nil {
+		return err
+	}
+	mapped := make(scanner.ErrorList, len(list))
+	for idx, e := range list {
+		mapped[idx] = &scanner.Error{Pos: m.position(e.Pos.Offset - a.offset), Msg: e.Msg}
+	}
+	return mapped
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"go/format"
+	"go/ast"
+	"go/parser"
 	"go/token"
 	"io"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -19,12 +25,26 @@ import (
 
 const (
 	flagFormatUsage = "providing the -f flag also prints formatted text"
+	flagStatsUsage  = "print the duration of each parse attempt and the winning kind"
+	flagDepthUsage  = "dump a compact tree limited to the given depth, counting the root as 1 and eliding deeper nodes"
+	flagExpandUsage = "print the expanded source that was parsed instead of the AST"
+	flagStrictUsage = "exit immediately with a non-zero status when an arg fails to parse"
+	flagDeclsUsage  = "parse each whole arg as a file, adding a package clause if it has none, then dump each top-level declaration separately"
+	flagReplUsage   = "read sources from stdin line by line, dumping each until EOF"
+	flagInputUsage  = "read sources from `format` args, or json to decode a JSON array or stream of {\"src\": ...} objects from stdin"
+	flagJSONUsage   = "print a JSON array with the kind, dump and any error of each source"
+	flagStyleUsage  = "format with the gofmt `style`, or gofumpt to also apply the subset of gofumpt's stricter rules which need no type information"
+	flagColorUsage  = "colorize the node types of the -depth dump, ignored when stdout is not a terminal"
+	flagPathUsage   = "dump only the node at the dot separated `path` of field names and indexes, such as Decls.0.Body"
+	flagCPUUsage    = "write a cpu profile covering the parsing of all args to `file`"
+	flagMemUsage    = "write a memory profile after parsing all args to `file`"
 	flagHelpUsage   = "display usage information and exit"
 	helpText        = `
 astdump is a simple utility to print ast related information for Go source. It
 simply constructs an AST and dumps it using "github.com/shurcooL/go-goon".
 
   *Warning* Do not use this utility with >15 lines, the output is very verbose.
+  Use the -depth flag to limit the output for larger sources.
 
 For more information please see:
 
@@ -41,8 +61,36 @@ Example:
   # Dump a small chunk of source from stdin.
   cat source.go | astdump -
 
-  # Dump and reformat the source text with -f
+  # Dump and reformat the source text with -f, optionally in a stricter -style
   cat source.go | astdump -f -
+  cat source.go | astdump -f -style gofumpt -
+
+  # Dump only the top 3 levels of the tree with -depth, colorizing node types
+  cat source.go | astdump -depth 3 -color -
+
+  # Dump only the body of the first declaration with -path
+  astdump -path Decls.0.Body - < source.go
+
+  # Dump each declaration of a file under its own header with -decls
+  astdump -decls -depth 4 - < source.go
+
+  # Explore interactively, incomplete lines such as 'if x {' are continued
+  astdump -repl
+
+  # Dump a JSON array or newline delimited stream of {"src": ...} objects as JSON
+  echo '[{"src": "x := 1"}, {"src": "f()"}]' | astdump -input json -json
+
+  # Show the scaffolded source that was actually parsed with -expanded
+  astdump -expanded 'foo := 42'
+
+  # Show how long each parse attempt took with -stats
+  astdump -stats 'func f() {}'
+
+  # Profile parsing a large batch of sources with -cpuprofile
+  astdump -cpuprofile cpu.out $(cat snippets.txt) > /dev/null
+
+The exit status is non-zero when any source fails to parse, use -strict to
+exit on the first failure.
 
 Usage:
 
@@ -56,17 +104,45 @@ Flags:
 var (
 	flagHelp   bool
 	flagFormat bool
+	flagStats  bool
+	flagDepth  int
+	flagExpand bool
+	flagStrict bool
+	flagDecls  bool
+	flagRepl   bool
+	flagInput  string
+	flagJSON   bool
+	flagPath   string
+	flagColor  bool
+	flagStyle  string
+	flagCPU    string
+	flagMem    string
 )
 
 var (
 	stdinNotice sync.Once
 	stdinReads  int64
+	profileStop sync.Once
+	profileCPU  *os.File
 )
 
 func init() {
 	flag.BoolVar(&flagHelp, "h", false, flagHelpUsage)
 	flag.BoolVar(&flagFormat, "fmt", false, flagFormatUsage)
 	flag.BoolVar(&flagFormat, "f", false, flagFormatUsage+` [short]`)
+	flag.BoolVar(&flagStats, "stats", false, flagStatsUsage)
+	flag.IntVar(&flagDepth, "depth", 0, flagDepthUsage)
+	flag.BoolVar(&flagExpand, "expanded", false, flagExpandUsage)
+	flag.BoolVar(&flagStrict, "strict", false, flagStrictUsage)
+	flag.BoolVar(&flagDecls, "decls", false, flagDeclsUsage)
+	flag.BoolVar(&flagRepl, "repl", false, flagReplUsage)
+	flag.StringVar(&flagInput, "input", "args", flagInputUsage)
+	flag.BoolVar(&flagJSON, "json", false, flagJSONUsage)
+	flag.StringVar(&flagPath, "path", "", flagPathUsage)
+	flag.BoolVar(&flagColor, "color", false, flagColorUsage)
+	flag.StringVar(&flagStyle, "style", styleGofmt, flagStyleUsage)
+	flag.StringVar(&flagCPU, "cpuprofile", "", flagCPUUsage)
+	flag.StringVar(&flagMem, "memprofile", "", flagMemUsage)
 }
 
 func doStdinNotice() {
@@ -98,7 +174,73 @@ func getStdinArg() string {
 	return string(b)
 }
 
+// jsonInput is a single source decoded from stdin with -input json.
+type jsonInput struct {
+	Src string `json:"src"`
+}
+
+// getJSONArgs decodes the sources from stdin, which may be a JSON array or a
+// stream of JSON objects.
+func getJSONArgs() []string {
+	if atomic.LoadInt64(&stdinReads) > 0 {
+		exit(1, `attempt to perform multiple reads from stdin`)
+	}
+	doStdinNotice()
+	defer atomic.AddInt64(&stdinReads, 1)
+
+	r := bufio.NewReader(io.LimitReader(os.Stdin, 1e6))
+	dec := json.NewDecoder(r)
+	var inputs []jsonInput
+	if b, err := peekNonSpace(r); err == nil && b == '[' {
+		if err := dec.Decode(&inputs); err != nil {
+			exit(1, "unable to decode json input: %v", err)
+		}
+	} else {
+		for {
+			var in jsonInput
+			if err := dec.Decode(&in); err == io.EOF {
+				break
+			} else if err != nil {
+				exit(1, "unable to decode json input #%v: %v", len(inputs), err)
+			}
+			inputs = append(inputs, in)
+		}
+	}
+
+	args := make([]string, len(inputs))
+	for idx, in := range inputs {
+		args[idx] = in.Src
+	}
+	return args
+}
+
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			r.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
+
 func getArgs() []string {
+	switch flagInput {
+	case `args`:
+	case `json`:
+		if len(flag.Args()) > 0 {
+			exit(1, `source args may not be given with -input json`)
+		}
+		return getJSONArgs()
+	default:
+		exit(1, "unknown -input format %q, expected args or json", flagInput)
+	}
+
 	args := flag.Args()
 	if len(args) == 0 {
 		args = append(args, `-`)
@@ -119,21 +261,359 @@ func main() {
 		os.Exit(0)
 	}
 
+	if mutlExcl(flagJSON, flagRepl || flagDecls || flagExpand) {
+		exit(1, `-json may not be used with -repl, -decls or -expanded`)
+	}
+	if mutlExcl(flagPath != ``, flagDecls || flagExpand) {
+		exit(1, `-path may not be used with -decls or -expanded`)
+	}
+	if flagStyle != styleGofmt && flagStyle != styleGofumpt {
+		exit(1, `-style must be one of gofmt or gofumpt, got %q`, flagStyle)
+	}
+	if flagColor && flagDepth <= 0 {
+		exit(1, `-color may only be used with -depth`)
+	}
+	if flagRepl {
+		if len(flag.Args()) > 0 || flagInput != `args` {
+			exit(1, `source args may not be given with -repl`)
+		}
+		startProfile()
+		repl(os.Stdin)
+		stopProfile()
+		return
+	}
+
+	var (
+		failed int
+		outs   []jsonOutput
+	)
 	args := getArgs()
+	startProfile()
 	for idx, arg := range args {
-		node := astfrom.Source(arg)
+		var err error
+		if flagJSON {
+			var out jsonOutput
+			out, err = jsonArg(arg)
+			outs = append(outs, out)
+		} else {
+			err = dumpArg(idx, arg)
+		}
+		if err != nil {
+			failed++
+			if flagStrict {
+				exit(1, "arg #%v failed to parse: %v", idx, err)
+			}
+		}
+	}
+	stopProfile()
+	if flagJSON {
+		printJSON(outs)
+	}
+	if failed > 0 {
+		exit(1, "%v of %v args failed to parse", failed, len(args))
+	}
+}
+
+// repl reads sources from r a line at a time, dumping each as an arg once it
+// is complete. Lines are buffered while the source is incomplete, so a source
+// may span many lines. Prompts are written to stderr when r is a terminal.
+func repl(r io.Reader) {
+	atomic.AddInt64(&stdinReads, 1)
+
+	var (
+		idx int
+		buf strings.Builder
+	)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1e6)
+	prompt(`> `)
+	for sc.Scan() {
+		buf.WriteString(sc.Text() + "\n")
+		src := buf.String()
+		if len(strings.TrimSpace(src)) == 0 {
+			buf.Reset()
+			prompt(`> `)
+			continue
+		}
+		if astfrom.IsIncomplete(src) {
+			prompt(`. `)
+			continue
+		}
+		replArg(idx, src)
+		idx++
+		buf.Reset()
+		prompt(`> `)
+	}
+	must(sc.Err())
+	if len(strings.TrimSpace(buf.String())) > 0 {
+		replArg(idx, buf.String())
+	}
+}
+
+func replArg(idx int, src string) {
+	if err := dumpArg(idx, src); err != nil {
+		if flagStrict {
+			exit(1, "arg #%v failed to parse: %v", idx, err)
+		}
+		fmt.Fprintf(os.Stderr, "arg #%v failed to parse: %v\n", idx, err)
+	}
+}
+
+func prompt(s string) {
+	if isTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, s)
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// jsonOutput is the result of a single source printed with -json.
+type jsonOutput struct {
+	Src       string        `json:"src"`
+	Kind      string        `json:"kind,omitempty"`
+	Dump      string        `json:"dump,omitempty"`
+	Formatted string        `json:"formatted,omitempty"`
+	Stats     []jsonAttempt `json:"stats,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+type jsonAttempt struct {
+	Kind     string        `json:"kind"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// jsonArg returns the output for arg according to the flags, along with any
+// error parsing it.
+func jsonArg(arg string) (jsonOutput, error) {
+	out := jsonOutput{Src: arg}
+	res := astfrom.Parse(arg)
+	if res.Err != nil {
+		out.Error = res.Err.Error()
+		return out, res.Err
+	}
+	node, err := astfrom.Select(res.Node, flagPath)
+	if err != nil {
+		out.Error = err.Error()
+		return out, err
+	}
+
+	out.Kind = res.Kind.String()
+	out.Dump = astfrom.Dump(node, flagDepth)
+	if flagFormat {
+		if formatted, err := formatResult(res); err == nil {
+			out.Formatted = formatted
+		}
+	}
+	if flagStats {
+		for _, a := range res.Attempts {
+			ja := jsonAttempt{Kind: a.Kind.String(), Duration: a.Duration}
+			if a.Err != nil {
+				ja.Error = a.Err.Error()
+			}
+			out.Stats = append(out.Stats, ja)
+		}
+	}
+	return out, nil
+}
+
+func printJSON(outs []jsonOutput) {
+	if outs == nil {
+		outs = []jsonOutput{}
+	}
+	b, err := json.MarshalIndent(outs, ``, `  `)
+	must(err)
+	fmt.Printf("%s\n", b)
+}
+
+// dumpArg prints the arg according to the flags, returning any error parsing
+// it.
+func dumpArg(idx int, arg string) error {
+	if flagDecls {
+		err := printDecls(idx, arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "arg #%v failed to parse: %v\n", idx, err)
+		}
+		return err
+	}
+
+	res := astfrom.Parse(arg)
+	node, err := res.Node, res.Err
+	if err == nil {
+		if node, err = astfrom.Select(node, flagPath); err != nil {
+			fmt.Fprintf(os.Stderr, "arg #%v: %v\n", idx, err)
+			return err
+		}
+	}
 
+	if flagExpand {
+		fmt.Printf("  --------  [Expanded - Arg #%v]  --------\n", idx)
+		printExpanded(res.Attempts, err)
+	} else {
 		fmt.Printf("  --------  [Source - Arg #%v]  --------\n", idx)
+		printNode(node)
+	}
+
+	if flagStats {
+		fmt.Printf("\n  --------  [Stats - Arg #%v]  --------\n", idx)
+		printStats(res.Attempts, err)
+		fmt.Printf("\n")
+	}
+
+	if flagFormat {
+		fmt.Printf("\n  --------  [Formatted - Arg #%v]  --------\n", idx)
+		printFormatted(idx, res)
+		fmt.Printf("\n\n")
+	}
+	return err
+}
+
+func startProfile() {
+	if flagCPU == `` {
+		return
+	}
+	f, err := os.Create(flagCPU)
+	must(err)
+	must(pprof.StartCPUProfile(f))
+	profileCPU = f
+}
+
+// stopProfile stops the cpu profile and writes the memory profile, it may be
+// called multiple times so that it runs before any exit.
+func stopProfile() {
+	profileStop.Do(func() {
+		if profileCPU != nil {
+			pprof.StopCPUProfile()
+			if err := profileCPU.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, `unable to write cpu profile:`, err)
+			}
+		}
+		if flagMem != `` {
+			if err := writeMemProfile(flagMem); err != nil {
+				fmt.Fprintln(os.Stderr, `unable to write memory profile:`, err)
+			}
+		}
+	})
+}
+
+func writeMemProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func printNode(node ast.Node) {
+	if flagDepth > 0 {
+		out := astfrom.Dump(node, flagDepth)
+		if flagColor && isTerminal(os.Stdout) {
+			out = colorize(out)
+		}
+		fmt.Print(out)
+	} else {
 		goon.Dump(node)
+	}
+}
+
+// nodeTypeRe matches the node types within the output of astfrom.Dump.
+var nodeTypeRe = regexp.MustCompile(`\*?ast\.[A-Z]\w*`)
+
+// colorize wraps each node type within dump in ANSI color codes.
+func colorize(dump string) string {
+	return nodeTypeRe.ReplaceAllString(dump, "\x1b[36m$0\x1b[0m")
+}
 
+// printDecls parses src as a file, adding a package clause if it has none, then
+// dumps each top-level declaration separately under its own header.
+func printDecls(idx int, src string) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, `source.go`, src, parser.ParseComments)
+	if err != nil && !hasPackageClause(src) {
+		fset = token.NewFileSet()
+		file, err = parser.ParseFile(
+			fset, `source.go`, "package main\n\n"+src, parser.ParseComments)
+	}
+	if err != nil {
+		return err
+	}
+
+	for decl, node := range file.Decls {
+		fmt.Printf("  --------  [Decl #%v - Arg #%v]  --------\n", decl, idx)
+		printNode(node)
 		if flagFormat {
-			fmt.Printf("\n  --------  [Formatted - Arg #%v]  --------\n", idx)
-			fset := token.NewFileSet()
-			err := format.Node(os.Stdout, fset, node)
-			must(err)
+			fmt.Printf("\n")
+			out, err := formatNode(fset, node)
+			if err != nil {
+				fmt.Fprintf(os.Stderr,
+					"unable to format decl #%v of arg #%v: %v\n", decl, idx, err)
+			}
+			fmt.Print(out)
 			fmt.Printf("\n\n")
 		}
 	}
+	return nil
+}
+
+func hasPackageClause(src string) bool {
+	_, err := parser.ParseFile(token.NewFileSet(), ``, src, parser.PackageClauseOnly)
+	return err == nil
+}
+
+// printExpanded prints the source of the final attempt, or err when the
+// complete parse including reduction failed.
+func printExpanded(attempts []astfrom.Attempt, err error) {
+	if err != nil || len(attempts) == 0 {
+		fmt.Printf("error: %v\n", err)
+		return
+	}
+	last := attempts[len(attempts)-1]
+	fmt.Printf("%v\n", strings.TrimRight(last.Src, "\n"))
+}
+
+// printFormatted prints the formatted node of res, reporting any error
+// formatting it on stderr rather than exiting so the remaining args are still
+// processed.
+func printFormatted(idx int, res *astfrom.ParseResult) {
+	out, err := formatResult(res)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to format arg #%v: %v\n", idx, err)
+		return
+	}
+	fmt.Print(out)
+}
+
+// printStats prints the duration of each attempt, or err when there were no
+// attempts. A winner is only reported when err, the error of the complete
+// parse including reduction, is nil.
+func printStats(attempts []astfrom.Attempt, err error) {
+	if len(attempts) == 0 {
+		fmt.Printf("  error: %v\n", err)
+		return
+	}
+	var total time.Duration
+	for _, a := range attempts {
+		total += a.Duration
+		status := `ok`
+		if a.Err != nil {
+			status = `failed`
+		}
+		fmt.Printf("  %-6v %12v  %v\n", a.Kind, a.Duration, status)
+	}
+
+	winner := `none`
+	if last := attempts[len(attempts)-1]; err == nil && last.Err == nil {
+		winner = last.Kind.String()
+	}
+	fmt.Printf("  %-6v %12v  winner: %v\n", `Total`, total, winner)
 }
 
 func mutlExcl(bools ...bool) bool {
@@ -154,6 +634,7 @@ func must(err error) {
 }
 
 func exit(code int, msg string, a ...interface{}) {
+	stopProfile()
 	if code == 0 {
 		fmt.Fprintf(os.Stdout, msg+"\n", a...)
 	} else {
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"github.com/cstockton/astgen/astfrom"
+)
+
+// The format styles accepted by the -style flag.
+const (
+	styleGofmt   = `gofmt`
+	styleGofumpt = `gofumpt`
+)
+
+// formatResult returns the node of res formatted in the style given by -style,
+// or the error its source failed to parse with.
+func formatResult(res *astfrom.ParseResult) (string, error) {
+	if res.Err != nil {
+		return ``, res.Err
+	}
+	return formatNode(res.Fset, res.Node)
+}
+
+// formatNode returns node formatted in the style given by -style. The gofumpt
+// style may modify node in place.
+func formatNode(fset *token.FileSet, node ast.Node) (string, error) {
+	if flagStyle == styleGofumpt {
+		gofumptNode(node)
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, node); err != nil {
+		return ``, err
+	}
+	if flagStyle == styleGofumpt {
+		return gofumptText(buf.String()), nil
+	}
+	return buf.String(), nil
+}
+
+// gofumptNode applies the rules of gofumpt which are changes to the tree:
+// octal integer literals use the 0o prefix and single var declarations are
+// not grouped with parentheses.
+func gofumptNode(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch T := n.(type) {
+		case *ast.BasicLit:
+			v := T.Value
+			if T.Kind == token.INT && len(v) > 1 && v[0] == '0' && strings.Trim(v, "01234567_") == `` {
+				T.Value = `0o` + strings.TrimPrefix(v[1:], `_`)
+			}
+		case *ast.GenDecl:
+			if T.Tok == token.VAR && len(T.Specs) == 1 && T.Lparen.IsValid() {
+				T.Lparen, T.Rparen = token.NoPos, token.NoPos
+			}
+		}
+		return true
+	})
+}
+
+// gofumptText applies the rules of gofumpt which only affect empty lines of
+// the formatted src: blocks, composite literals and field lists have no
+// leading or trailing empty lines, and an error check has no empty line
+// between it and the assignment of the error. Lines within multi-line raw
+// strings are never changed.
+func gofumptText(src string) string {
+	raw := rawLines(src)
+	lines := strings.Split(src, "\n")
+	out := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if raw[i+1] || len(strings.TrimSpace(line)) > 0 || len(out) == 0 || i+1 == len(lines) {
+			out = append(out, line)
+			continue
+		}
+
+		prev, next := strings.TrimSpace(out[len(out)-1]), strings.TrimSpace(lines[i+1])
+		switch {
+		case strings.HasSuffix(prev, `{`), strings.HasSuffix(prev, `(`):
+		case strings.HasPrefix(next, `}`), strings.HasPrefix(next, `)`):
+		case next == `if err != nil {` && assignsErr(prev):
+		default:
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// assignsErr reports whether the statement on line assigns to err.
+func assignsErr(line string) bool {
+	lhs, _, ok := strings.Cut(line, `=`)
+	lhs = strings.TrimSuffix(lhs, `:`)
+	for _, name := range strings.Split(lhs, `,`) {
+		if strings.TrimSpace(name) == `err` {
+			return ok
+		}
+	}
+	return false
+}
+
+// rawLines returns the 1-based lines of src which follow the first line of a
+// multi-line raw string literal, so their content is left unchanged.
+func rawLines(src string) map[int]bool {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile(``, fset.Base(), len(src))
+	s.Init(file, []byte(src), nil, 0)
+
+	lines := make(map[int]bool)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return lines
+		}
+		if tok == token.STRING && strings.HasPrefix(lit, "`") {
+			start := file.Line(pos)
+			for n := 1; n <= strings.Count(lit, "\n"); n++ {
+				lines[start+n] = true
+			}
+		}
+	}
+}